@@ -6,9 +6,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,6 +26,7 @@ var server string
 var token string
 var skipDiscussion bool
 var prLink string
+var commitSHA string
 
 func main() {
 	rfdNum := flag.String("rfd", "", "If passed will operate on single rfd")
@@ -34,10 +37,12 @@ func main() {
 	rfdFolder := flag.String("rfd-folder", "adr", "folder containing ADRs within repo")
 	skipDisc := flag.Bool("skip-discussion", false, "skip creating discussions (for bulk imports)")
 	prLinkFlag := flag.String("pr-link", "", "URL to the open PR for this RFD (from CI context)")
+	commitFlag := flag.String("commit", os.Getenv("GITHUB_SHA"), "commit SHA the RFD was read from, recorded in revision history (defaults to $GITHUB_SHA)")
 	flag.Parse()
 
 	skipDiscussion = *skipDisc
 	prLink = *prLinkFlag
+	commitSHA = *commitFlag
 
 	r, _ := regexp.Compile(`^\d{4}`)
 
@@ -65,6 +70,11 @@ func main() {
 		for _, rfd := range rfds {
 			if err := sendRFD(&rfd); err != nil {
 				log.Printf("Warning: failed to send RFD %s: %v", rfd.ID, err)
+				continue
+			}
+
+			if err := sendRFDFiles(*folder, rfd.ID); err != nil {
+				log.Printf("Warning: failed to send files for RFD %s: %v", rfd.ID, err)
 			}
 		}
 
@@ -100,6 +110,10 @@ func main() {
 	if err := sendRFD(rfd); err != nil {
 		fatal("Failed to send RFD %s: %v", validatedRfdNum, err)
 	}
+
+	if err := sendRFDFiles(rfdDir, validatedRfdNum); err != nil {
+		fatal("Failed to send files for RFD %s: %v", validatedRfdNum, err)
+	}
 }
 
 func fatal(format string, args ...interface{}) {
@@ -118,9 +132,17 @@ func sendRFD(rfd *models.RFD) error {
 		return err
 	}
 
-	url := fmt.Sprintf("%s/api/v1/rfds/%s", server, rfd.ID)
+	query := neturl.Values{}
 	if skipDiscussion {
-		url += "?skip_discussion=true"
+		query.Set("skip_discussion", "true")
+	}
+	if commitSHA != "" {
+		query.Set("commit", commitSHA)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/rfds/%s", server, rfd.ID)
+	if len(query) > 0 {
+		url += "?" + query.Encode()
 	}
 	req, err := http.NewRequest("POST", url, buf)
 	if err != nil {
@@ -152,6 +174,55 @@ func sendRFD(rfd *models.RFD) error {
 	return nil
 }
 
+// sendRFDFiles uploads everything in the RFD's folder other than markdown, such as
+// images its README.md links to, so the server can serve them
+func sendRFDFiles(rfdDir string, rfdNum string) error {
+	root := filepath.Join(rfdDir, rfdNum)
+
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || strings.HasSuffix(strings.ToLower(entry.Name()), ".md") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		url := fmt.Sprintf("%s/api/v1/rfds/%s/files/%s", server, rfdNum, (&neturl.URL{Path: filepath.ToSlash(relPath)}).EscapedPath())
+		req, err := http.NewRequest("PUT", url, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Add("api-token", token)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("uploading %s returned %d: %s", relPath, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		log.Printf("Uploaded %s for RFD %s", relPath, rfdNum)
+
+		return nil
+	})
+}
+
 func getRFDs(worktree string) ([]models.RFD, error) {
 	rfdDir := worktree
 	files, err := ioutil.ReadDir(rfdDir)
@@ -261,6 +332,10 @@ func importFromBranches(repoPath string, rfdFolder string) error {
 			continue
 		}
 
+		if err := sendRFDFiles(filepath.Join(repoPath, rfdFolder), rfdNum); err != nil {
+			log.Printf("Warning: failed to send files for RFD %s: %v\n", rfdNum, err)
+		}
+
 		if existsInMain {
 			log.Printf("Updated RFD %s from branch (exists in main, branch may have updates)\n", rfdNum)
 			updated++
@@ -2,7 +2,10 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
 
 	"github.com/geekgonecrazy/rfd-tool/config"
 	"github.com/geekgonecrazy/rfd-tool/core"
@@ -10,6 +13,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	configFile := flag.String("configFile", "config.yaml", "Config File full path. Defaults to current folder")
 
 	flag.Parse()
@@ -22,8 +30,64 @@ func main() {
 		log.Fatalln("Failed initializing core: ", err)
 	}
 
+	core.StartRepoSync()
+	core.StartRepoWriteWorker()
+
 	if err := router.Run(); err != nil {
 		log.Fatalln("Failed to start router: ", err)
 	}
 
 }
+
+// runMigrate handles `rfd-server migrate [-configFile config.yaml] [up|status]`
+func runMigrate(args []string) {
+	migrateFlags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configFile := migrateFlags.String("configFile", "config.yaml", "Config File full path. Defaults to current folder")
+	migrateFlags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: rfd-server migrate [-configFile config.yaml] [up|status]")
+		migrateFlags.PrintDefaults()
+	}
+
+	migrateFlags.Parse(args)
+
+	if err := config.Load(*configFile); err != nil {
+		log.Fatalln("Error loading Config file: ", err)
+	}
+
+	action := migrateFlags.Arg(0)
+	if action == "" {
+		action = "up"
+	}
+
+	switch action {
+	case "up":
+		if err := core.MigrateUp(); err != nil {
+			log.Fatalln("Failed to apply migrations: ", err)
+		}
+
+		log.Println("Database is up to date")
+	case "status":
+	default:
+		migrateFlags.Usage()
+		os.Exit(2)
+	}
+
+	statuses, err := core.GetMigrationStatus()
+	if err != nil {
+		log.Fatalln("Failed to get migration status: ", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		appliedAt := ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+}
@@ -2,10 +2,14 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/geekgonecrazy/rfd-tool/forge"
+	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/gin-gonic/gin"
 
 	yaml "gopkg.in/yaml.v2"
@@ -14,17 +18,25 @@ import (
 // Config contains a reference to the configuration
 var Config *config
 
+const defaultSyncInterval = 5 * time.Minute
+
 type config struct {
 	Site              siteConfig     `yaml:"site" json:"site"`
 	DataPath          string         `yaml:"dataPath" json:"dataPath"`
-	Store             string         `yaml:"store" json:"store"`               // "sqlite" (default: sqlite)
-	DatabaseName      string         `yaml:"databaseName" json:"databaseName"` // Database filename (default: rfd.db)
-	APISecret         string         `yaml:"apiSecret" json:"apiSecret"`
+	Store             string         `yaml:"store" json:"store"`                     // "sqlite" or "postgres" (default: sqlite)
+	DatabaseName      string         `yaml:"databaseName" json:"databaseName"`       // Database filename (default: rfd.db)
+	DatabaseDSN       string         `yaml:"databaseDSN" json:"databaseDSN"`         // Postgres connection string, used when store is postgres
+	APISecret         string         `yaml:"apiSecret" json:"apiSecret"`             // Shared API secret, only accepted when legacyApiSecret is set
+	LegacyAPISecret   bool           `yaml:"legacyApiSecret" json:"legacyApiSecret"` // Accept apiSecret alongside personal API tokens
 	OIDC              oidcConfig     `yaml:"oidc" json:"oidc"`
 	Github            githubConfig   `yaml:"github" json:"github"`
 	Repo              repoConfig     `yaml:"repo" json:"repo"`
 	JWT               jwtConfig      `yaml:"jwt" json:"jwt"`
 	Webhook           *webhookConfig `yaml:"webhook" json:"webhook"`
+	Sync              syncConfig     `yaml:"sync" json:"sync"`
+	States            statesConfig   `yaml:"states" json:"states"`
+	Forge             forgeConfig    `yaml:"forge" json:"forge"`
+	Roles             rolesConfig    `yaml:"roles" json:"roles"`
 	RocketChatWebhook string         `yaml:"rocketchatWebhook" json:"rocketchatWebhook"` // Deprecated: use webhook instead
 }
 
@@ -33,6 +45,91 @@ type webhookConfig struct {
 	Secret string `yaml:"secret" json:"secret"`
 }
 
+type syncConfig struct {
+	Enabled        bool   `yaml:"enabled" json:"enabled"`
+	Interval       string `yaml:"interval" json:"interval"`             // Go duration, e.g. "5m" (default: 5m)
+	SkipDiscussion bool   `yaml:"skipDiscussion" json:"skipDiscussion"` // Don't create discussions for RFDs found by sync
+}
+
+// IntervalDuration returns the parsed sync interval, falling back to the default
+func (s syncConfig) IntervalDuration() time.Duration {
+	interval, err := time.ParseDuration(s.Interval)
+	if err != nil || interval <= 0 {
+		return defaultSyncInterval
+	}
+
+	return interval
+}
+
+type statesConfig struct {
+	Enforce     bool                `yaml:"enforce" json:"enforce"`         // Reject illegal transitions instead of flagging them
+	Transitions map[string][]string `yaml:"transitions" json:"transitions"` // state -> states it may move to (default: models.DefaultStateTransitions)
+}
+
+// AllowedTransitions returns the configured state transitions, falling back to the defaults
+func (s statesConfig) AllowedTransitions() models.StateTransitions {
+	if len(s.Transitions) == 0 {
+		return models.DefaultStateTransitions
+	}
+
+	transitions, err := models.ParseStateTransitions(s.Transitions)
+	if err != nil {
+		return models.DefaultStateTransitions
+	}
+
+	return transitions
+}
+
+type rolesConfig struct {
+	Default string            `yaml:"default" json:"default"` // Role for signed in users in none of the groups (default: viewer, admin when no groups are configured)
+	Groups  map[string]string `yaml:"groups" json:"groups"`   // OIDC group -> role
+}
+
+// RoleForGroups returns the highest role any of the groups is given, falling back to the default role
+func (r rolesConfig) RoleForGroups(groups []string) models.Role {
+	role := r.DefaultRole()
+
+	for _, group := range groups {
+		for name, groupRole := range r.Groups {
+			if strings.EqualFold(name, group) && models.Role(groupRole).Allows(role) {
+				role = models.Role(groupRole)
+			}
+		}
+	}
+
+	return role
+}
+
+// DefaultRole returns roles.default. Without any groups everyone signed in
+// is an admin, same as before roles existed.
+func (r rolesConfig) DefaultRole() models.Role {
+	if r.Default != "" {
+		return models.Role(r.Default)
+	}
+
+	if len(r.Groups) == 0 {
+		return models.RoleAdmin
+	}
+
+	return models.RoleViewer
+}
+
+type forgeConfig struct {
+	Type             string `yaml:"type" json:"type"`                         // github, gitlab, gitea or git (default: detected from repo.url)
+	APIURL           string `yaml:"apiUrl" json:"apiUrl"`                     // Defaults to the forge's API on repo.url's host
+	Token            string `yaml:"token" json:"token"`                       // Token allowed to open pull requests on repo.url
+	OpenPullRequests bool   `yaml:"openPullRequests" json:"openPullRequests"` // Open a PR when an RFD is created or enters discussion
+}
+
+// ForgeType returns the configured forge type, detecting it from repo.url when not set
+func (c *config) ForgeType() string {
+	if c.Forge.Type != "" {
+		return c.Forge.Type
+	}
+
+	return forge.DetectType(c.Repo.URL)
+}
+
 type siteConfig struct {
 	Name    string `yaml:"name" json:"name"`
 	URL     string `yaml:"url" json:"url"`
@@ -50,6 +147,23 @@ type oidcConfig struct {
 	AuthURL      string `yaml:"authUrl" json:"authUrl"`
 	TokenURL     string `yaml:"tokenUrl" json:"tokenUrl"`
 	IssuerURL    string `yaml:"issuerUrl" json:"issuerUrl"`
+
+	// Scopes to request, defaults to openid, profile and email, plus groups when roles.groups is set
+	Scopes []string `yaml:"scopes" json:"scopes"`
+}
+
+// OIDCScopes returns the scopes to ask the OIDC provider for
+func (c *config) OIDCScopes() []string {
+	if len(c.OIDC.Scopes) > 0 {
+		return c.OIDC.Scopes
+	}
+
+	scopes := []string{"openid", "profile", "email"}
+	if len(c.Roles.Groups) > 0 {
+		scopes = append(scopes, "groups")
+	}
+
+	return scopes
 }
 
 type repoConfig struct {
@@ -60,12 +174,58 @@ type repoConfig struct {
 	CommitAuthorName  string `yaml:"commitAuthorName" json:"commitAuthorName"`
 	CommitAuthorEmail string `yaml:"commitAuthorEmail" json:"commitAuthorEmail"`
 	PrivateDeployKey  string `yaml:"privateDeployKey" json:"privateDeployKey"`
+
+	// Auth is how git authenticates with the repo, SSH with username and privateDeployKey when not set
+	Auth repoAuthConfig `yaml:"auth" json:"auth"`
+}
+
+// Repo auth types
+const (
+	RepoAuthSSH   = "ssh"
+	RepoAuthHTTPS = "https"
+)
+
+type repoAuthConfig struct {
+	Type       string `yaml:"type" json:"type"`             // ssh or https (default: ssh)
+	Username   string `yaml:"username" json:"username"`     // ssh user or https basic auth user (default: repo.username for ssh, picked by the forge for https)
+	PrivateKey string `yaml:"privateKey" json:"privateKey"` // ssh only (default: repo.privateDeployKey)
+	Token      string `yaml:"token" json:"token"`           // https only, a token or password
+}
+
+// AuthType returns repo.auth.type, defaulting to ssh
+func (r repoConfig) AuthType() string {
+	if r.Auth.Type == "" {
+		return RepoAuthSSH
+	}
+
+	return r.Auth.Type
+}
+
+// SSHUsername returns the user to connect to the repo as over ssh
+func (r repoConfig) SSHUsername() string {
+	if r.Auth.Username != "" {
+		return r.Auth.Username
+	}
+
+	return r.Username
+}
+
+// SSHPrivateKey returns the key to connect to the repo with over ssh
+func (r repoConfig) SSHPrivateKey() string {
+	if r.Auth.PrivateKey != "" {
+		return r.Auth.PrivateKey
+	}
+
+	return r.PrivateDeployKey
 }
 
 type githubConfig struct {
 	PublicKey    []byte `yaml:"publicKey"`
 	ClientID     string `yaml:"clientId" json:"clientId"`
 	ClientSecret string `yaml:"clientSecret" json:"clientSecret"`
+
+	// WebhookSecret verifies push events sent to /hooks/github
+	WebhookSecret string `yaml:"webhookSecret" json:"webhookSecret"`
 }
 
 func (c *config) Load(filePath string) error {
@@ -92,6 +252,73 @@ func (c *config) VerifySettings() error {
 		return errors.New("dataPath must end with '/'")
 	}
 
+	if c.Store == "postgres" && c.DatabaseDSN == "" {
+		return errors.New("databaseDSN is required when store is postgres")
+	}
+
+	if c.Sync.Interval != "" {
+		interval, err := time.ParseDuration(c.Sync.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid sync.interval: %w", err)
+		}
+
+		if interval < time.Minute {
+			return errors.New("sync.interval must be at least 1m")
+		}
+	}
+
+	switch c.Repo.AuthType() {
+	case RepoAuthSSH:
+		// The key itself is checked by core.Setup, migrations don't need one
+		if c.Repo.Auth.Token != "" {
+			return errors.New("repo.auth.token is only used with repo.auth.type https")
+		}
+	case RepoAuthHTTPS:
+		if c.Repo.Auth.Token == "" {
+			return errors.New("repo.auth.token is required for https")
+		}
+
+		if c.Repo.Auth.PrivateKey != "" {
+			return errors.New("repo.auth.privateKey is only used with repo.auth.type ssh")
+		}
+	default:
+		return fmt.Errorf("invalid repo.auth.type '%s' (valid options: ssh, https)", c.Repo.Auth.Type)
+	}
+
+	switch c.ForgeType() {
+	case forge.TypeGithub, forge.TypeGitlab, forge.TypeGitea, forge.TypeGit:
+	default:
+		return fmt.Errorf("invalid forge.type '%s' (valid options: github, gitlab, gitea, git)", c.Forge.Type)
+	}
+
+	if c.Forge.OpenPullRequests {
+		if c.ForgeType() == forge.TypeGit {
+			return errors.New("forge.type git can't open pull requests")
+		}
+
+		if c.Forge.Token == "" {
+			return errors.New("forge.token is required to open pull requests")
+		}
+	}
+
+	if c.LegacyAPISecret && c.APISecret == "" {
+		return errors.New("apiSecret is required when legacyApiSecret is set")
+	}
+
+	if !c.Roles.DefaultRole().Valid() {
+		return fmt.Errorf("invalid roles.default '%s' (valid options: viewer, author, admin)", c.Roles.Default)
+	}
+
+	for group, role := range c.Roles.Groups {
+		if !models.Role(role).Valid() {
+			return fmt.Errorf("invalid role '%s' for group '%s' (valid options: viewer, author, admin)", role, group)
+		}
+	}
+
+	if _, err := models.ParseStateTransitions(c.States.Transitions); err != nil {
+		return fmt.Errorf("invalid states.transitions: %w", err)
+	}
+
 	return nil
 }
 
@@ -1,7 +1,8 @@
 package controllers
 
 import (
-	"fmt"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 
@@ -12,7 +13,7 @@ import (
 
 // GetRFDsHandler returns list of rfds in json
 func GetRFDsHandler(c *gin.Context) {
-	rfds, err := core.GetRFDs()
+	rfds, err := core.GetRFDs(requestViewer(c))
 	if err != nil {
 		handleError(c, "get rfds", err)
 		return
@@ -30,13 +31,13 @@ func GetRFDHandler(c *gin.Context) {
 		return
 	}
 
-	rfd, err := core.GetRFDByID(id)
+	rfd, err := core.GetRFDByID(id, requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting rfd by id", err)
 		return
 	}
 
-	if rfd == nil {
+	if rfd == nil || !rfd.VisibleTo(requestViewer(c)) {
 		c.AbortWithStatus(http.StatusNotFound)
 		return
 	}
@@ -45,8 +46,6 @@ func GetRFDHandler(c *gin.Context) {
 }
 
 func CreateRFDHandler(c *gin.Context) {
-	rfdUI := c.Query("rfdui")
-
 	var createPayload models.RFDCreatePayload
 
 	if err := c.ShouldBind(&createPayload); err != nil {
@@ -54,22 +53,34 @@ func CreateRFDHandler(c *gin.Context) {
 		return
 	}
 
-	rfd, err := core.CreateRFD(&createPayload)
+	rfd, err := core.CreateRFD(&createPayload, requestActor(c))
 	if err != nil {
+		if errors.Is(err, core.ErrTemplateNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
 		handleErrorJSON(c, "error creating RFD", err)
 		return
 	}
 
-	if rfdUI == "true" {
-		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("/created?rfd=%s", rfd.ID))
+	c.JSON(http.StatusCreated, rfd)
+}
+
+// GetTemplatesHandler lists the templates RFDs can be created from
+func GetTemplatesHandler(c *gin.Context) {
+	templates, err := core.GetRFDTemplates()
+	if err != nil {
+		handleErrorJSON(c, "getting templates", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, rfd)
+	c.JSON(http.StatusOK, templates)
 }
 
 // CreateOrUpdateRFDHandler create rfd
 // Use ?skip_discussion=true to skip creating a discussion (useful for bulk imports)
+// Use ?commit=<sha> to record which commit the content came from
 func CreateOrUpdateRFDHandler(c *gin.Context) {
 	var rfd models.RFD
 	if err := c.BindJSON(&rfd); err != nil {
@@ -77,21 +88,184 @@ func CreateOrUpdateRFDHandler(c *gin.Context) {
 		return
 	}
 
-	log.Println(rfd)
+	// Restricted RFDs can only be overwritten by someone who can see them
+	canUpdate, err := core.CanUpdateRFD(rfd.ID, requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "checking rfd visibility", err)
+		return
+	}
+
+	if !canUpdate {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
 
 	skipDiscussion := c.Query("skip_discussion") == "true"
 
-	if err := core.CreateOrUpdateRFD(&rfd, skipDiscussion); err != nil {
+	source := &models.ChangeSource{Actor: requestActor(c), CommitSHA: c.Query("commit")}
+
+	if err := core.CreateOrUpdateRFD(&rfd, skipDiscussion, source); err != nil {
+		if errors.Is(err, core.ErrIllegalStateTransition) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
 		handleErrorJSON(c, "creating rfd", err)
 		return
 	}
 
+	log.Printf("RFD %s updated over the API by %s", rfd.ID, source.Actor)
+
+	c.JSON(http.StatusOK, gin.H{"rfd": rfd})
+}
+
+// ChangeRFDStateHandler moves an RFD to a new state, committing the change to its README.md
+func ChangeRFDStateHandler(c *gin.Context) {
+	var payload models.RFDStatePayload
+	if err := c.ShouldBind(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	rfd, err := core.ChangeRFDState(c.Param("id"), payload.State, requestActor(c), requestViewer(c))
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidState) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, core.ErrIllegalStateTransition) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, core.ErrMergeConflict) {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		handleErrorJSON(c, "changing rfd state", err)
+		return
+	}
+
+	if rfd == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"rfd": rfd})
 }
 
+// GetRFDRevisionsHandler returns the revision history of an RFD, newest first
+func GetRFDRevisionsHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	rfd, err := core.GetRFDByID(id, requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "getting rfd by id", err)
+		return
+	}
+
+	if rfd == nil || !rfd.VisibleTo(requestViewer(c)) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	revisions, err := core.GetRFDRevisions(rfd.ID)
+	if err != nil {
+		handleErrorJSON(c, "getting rfd revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// GetRFDRelationsHandler returns the RFDs an RFD supersedes, depends on or is related to,
+// and the ones that supersede or depend on it
+func GetRFDRelationsHandler(c *gin.Context) {
+	rfd, err := core.GetRFDByID(c.Param("id"), requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "getting rfd by id", err)
+		return
+	}
+
+	if rfd == nil || !rfd.VisibleTo(requestViewer(c)) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	relations, err := core.GetRFDRelations(rfd.ID, requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "getting rfd relations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, relations)
+}
+
+// GetRFDFilesHandler lists the attachments stored alongside an RFD
+func GetRFDFilesHandler(c *gin.Context) {
+	rfd, err := core.GetRFDByID(c.Param("id"), requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "getting rfd by id", err)
+		return
+	}
+
+	if rfd == nil || !rfd.VisibleTo(requestViewer(c)) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	files, err := core.GetRFDFiles(rfd.ID)
+	if err != nil {
+		handleErrorJSON(c, "getting rfd files", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+// UploadRFDFileHandler stores an attachment for an RFD, the request body is the file's content
+func UploadRFDFileHandler(c *gin.Context) {
+	content, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, core.MaxRFDFileSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	file, err := core.SaveRFDFile(c.Param("id"), c.Param("path"), content, requestActor(c), requestViewer(c))
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidFilePath) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		handleErrorJSON(c, "saving rfd file", err)
+		return
+	}
+
+	if file == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// SearchHandler full text searches RFDs
+func SearchHandler(c *gin.Context) {
+	results, err := core.SearchRFDs(c.Query("q"), requestViewer(c))
+	if err != nil {
+		handleErrorJSON(c, "searching rfds", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
 // GetTagsHandler returns list of tags in json
 func GetTagsHandler(c *gin.Context) {
-	tags, err := core.GetTags()
+	tags, err := core.GetTags(requestViewer(c))
 	if err != nil {
 		handleError(c, "get tags", err)
 		return
@@ -109,7 +283,7 @@ func GetRFDsForTagHandler(c *gin.Context) {
 		return
 	}
 
-	rfds, err := core.GetRFDsByTag(tag)
+	rfds, err := core.GetRFDsByTag(tag, requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting rfd by id", err)
 		return
@@ -179,7 +353,7 @@ func GetAuthorRFDsHandler(c *gin.Context) {
 	}
 
 	// Get RFDs by the author ID
-	rfds, err := core.GetRFDsByAuthor(author.ID)
+	rfds, err := core.GetRFDsByAuthor(author.ID, requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting RFDs by author", err)
 		return
@@ -187,3 +361,21 @@ func GetAuthorRFDsHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"rfds": rfds})
 }
+
+// GetSyncStatusHandler returns the status of the repo sync loop
+func GetSyncStatusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, core.GetSyncStatus())
+}
+
+// TriggerSyncHandler kicks off a repo sync in the background
+func TriggerSyncHandler(c *gin.Context) {
+	log.Printf("Repo sync requested by %s", requestActor(c))
+
+	go func() {
+		if err := core.SyncRepo(); err != nil {
+			log.Printf("Repo sync failed: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"success": true})
+}
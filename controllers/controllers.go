@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/geekgonecrazy/rfd-tool/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -32,6 +33,27 @@ func handleErrorJSON(c *gin.Context, verboseMsg string, err error) {
 	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "requestId": id})
 }
 
+// requestActor returns who is making the request, for attribution in revision history
+func requestActor(c *gin.Context) string {
+	if email := c.GetString("userEmail"); email != "" {
+		return email
+	}
+
+	return "api"
+}
+
+// requestRole is the signed in user's role, empty when not signed in
+func requestRole(c *gin.Context) models.Role {
+	return models.Role(c.GetString("userRole"))
+}
+
+// requestViewer is who the request is showing RFDs to, signed out when nobody is
+func requestViewer(c *gin.Context) models.Viewer {
+	value, _ := c.Get("viewer")
+	viewer, _ := value.(models.Viewer)
+	return viewer
+}
+
 // LivenessCheckHandler liveness check
 func LivenessCheckHandler(c *gin.Context) {
 	c.AbortWithStatus(http.StatusOK)
@@ -1 +1,52 @@
 package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/core"
+	"github.com/geekgonecrazy/rfd-tool/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// GitHub caps webhook payloads at 25MB
+const maxGithubPayloadSize = 25 << 20
+
+// GithubWebhookHandler receives GitHub push events and re-syncs the RFDs they touched
+func GithubWebhookHandler(c *gin.Context) {
+	secret := config.Config.Github.WebhookSecret
+	if secret == "" {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxGithubPayloadSize))
+	if err != nil {
+		handleErrorJSON(c, "reading github webhook body", err)
+		return
+	}
+
+	if !webhook.VerifySignature(body, c.GetHeader("X-Hub-Signature-256"), secret) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	switch c.GetHeader("X-GitHub-Event") {
+	case webhook.GithubEventPing:
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	case webhook.GithubEventPush:
+		var event webhook.PushEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid push payload"})
+			return
+		}
+
+		rfds := core.HandleGithubPush(&event)
+
+		c.JSON(http.StatusAccepted, gin.H{"success": true, "rfds": rfds})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"success": true, "ignored": true})
+	}
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/geekgonecrazy/rfd-tool/config"
 	"github.com/geekgonecrazy/rfd-tool/core"
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +18,7 @@ func OIDCAuthorizationURLHandler(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("_sess", token, expireAt, "/", "", false, false)
+	setSessionCookie(c, token, expireAt)
 
 	c.Redirect(http.StatusTemporaryRedirect, url)
 
@@ -39,7 +41,17 @@ func OIDCCallbackHandler(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("_sess", token, expireAt, "/", "", false, false)
+	setSessionCookie(c, token, expireAt)
 
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
+
+// setSessionCookie sets the _sess cookie, cleared when maxAge is negative. Scripts can't
+// read it, and it's only sent over https when the site is served over https. Lax still
+// sends it when the OIDC provider redirects back, but not with posts from other sites.
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	secure := strings.HasPrefix(strings.ToLower(config.Config.Site.URL), "https://")
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("_sess", token, maxAge, "/", "", secure, true)
+}
@@ -1,29 +1,28 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/geekgonecrazy/rfd-tool/config"
 	"github.com/geekgonecrazy/rfd-tool/core"
+	"github.com/geekgonecrazy/rfd-tool/diff"
 	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/gin-gonic/gin"
 )
 
-// DefaultRouteHandler shows RFD list - all RFDs if logged in, only public if not
+// DefaultRouteHandler shows RFD list - the RFDs the user can see if logged in, only public if not
 func DefaultRouteHandler(c *gin.Context) {
 	isPublicView := c.GetBool("isPublicView")
 	loggedIn := c.GetBool("loggedIn")
 
-	var rfds []models.RFD
-	var err error
-
-	if loggedIn {
-		rfds, err = core.GetRFDs()
-	} else {
-		rfds, err = core.GetPublicRFDs()
-	}
-
+	rfds, err := core.GetRFDs(requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting rfds", err)
 		return
@@ -33,6 +32,7 @@ func DefaultRouteHandler(c *gin.Context) {
 		"siteName":     config.Config.Site.Name,
 		"rfds":         rfds,
 		"isLoggedIn":   loggedIn,
+		"canCreate":    requestRole(c).Allows(models.RoleAuthor),
 		"isPublicView": isPublicView,
 	})
 }
@@ -59,15 +59,8 @@ func AuthorListPageHandler(c *gin.Context) {
 		return
 	}
 
-	var rfds []models.RFD
-	if loggedIn {
-		// Logged in: get all RFDs by this author
-		rfds, err = core.GetRFDsByAuthor(author.ID)
-	} else {
-		// Not logged in: get only public RFDs by this author
-		rfds, err = core.GetPublicRFDsByAuthorID(authorID)
-	}
-
+	// Only the author's RFDs the viewer can see, just the public ones when not logged in
+	rfds, err := core.GetRFDsByAuthor(author.ID, requestViewer(c))
 	if err != nil {
 		handleError(c, "getting rfds by author", err)
 		return
@@ -84,6 +77,7 @@ func AuthorListPageHandler(c *gin.Context) {
 		"rfds":         rfds,
 		"authorFilter": authorDisplayName,
 		"isLoggedIn":   loggedIn,
+		"canCreate":    requestRole(c).Allows(models.RoleAuthor),
 		"isPublicView": isPublicView,
 	})
 }
@@ -99,25 +93,53 @@ func TagListPageHandler(c *gin.Context) {
 		return
 	}
 
-	var rfds []models.RFD
-	var err error
+	rfds, err := core.GetRFDsByTag(tag, requestViewer(c))
+	if err != nil {
+		handleError(c, "getting rfds by tag", err)
+		return
+	}
 
-	if loggedIn {
-		rfds, err = core.GetRFDsByTag(tag)
-	} else {
-		rfds, err = core.GetPublicRFDsByTag(tag)
+	c.HTML(http.StatusOK, "rfdList.tmpl", gin.H{
+		"siteName":     config.Config.Site.Name,
+		"rfds":         rfds,
+		"tagFilter":    tag,
+		"isLoggedIn":   loggedIn,
+		"canCreate":    requestRole(c).Allows(models.RoleAuthor),
+		"isPublicView": isPublicView,
+	})
+}
+
+// SearchPageHandler shows RFDs matching a full text search that the viewer can see
+func SearchPageHandler(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	isPublicView := c.GetBool("isPublicView")
+	loggedIn := c.GetBool("loggedIn")
+
+	if query == "" {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		return
 	}
 
+	results, err := core.SearchRFDs(query, requestViewer(c))
 	if err != nil {
-		handleError(c, "getting rfds by tag", err)
+		handleError(c, "searching rfds", err)
 		return
 	}
 
+	rfds := make([]models.RFD, 0, len(results))
+	snippets := map[string]template.HTML{}
+	for _, result := range results {
+		rfds = append(rfds, result.RFD)
+		snippets[result.RFD.ID] = template.HTML(result.Snippet)
+	}
+
 	c.HTML(http.StatusOK, "rfdList.tmpl", gin.H{
 		"siteName":     config.Config.Site.Name,
 		"rfds":         rfds,
-		"tagFilter":    tag,
+		"snippets":     snippets,
+		"searchQuery":  query,
 		"isLoggedIn":   loggedIn,
+		"canCreate":    requestRole(c).Allows(models.RoleAuthor),
 		"isPublicView": isPublicView,
 	})
 }
@@ -137,7 +159,7 @@ func LoginPageHandler(c *gin.Context) {
 // LogoutHandler clears the session cookie and redirects to login page
 func LogoutHandler(c *gin.Context) {
 	// Clear the session cookie
-	c.SetCookie("_sess", "", -1, "/", "", false, true)
+	setSessionCookie(c, "", -1)
 	c.Redirect(http.StatusTemporaryRedirect, "/login")
 }
 
@@ -152,7 +174,7 @@ func RFDPageHandler(c *gin.Context) {
 		return
 	}
 
-	rfd, err := core.GetRFDByID(id)
+	rfd, err := core.GetRFDByID(id, requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting rfd by id", err)
 		return
@@ -163,19 +185,322 @@ func RFDPageHandler(c *gin.Context) {
 		return
 	}
 
+	// Revision and state history names who changed what, so only show it to signed in users
+	var revisions []models.RFDRevision
+	var transitions []models.StateTransition
+	var merges []models.RFDMerge
+	if loggedIn {
+		revisions, err = core.GetRFDRevisions(rfd.ID)
+		if err != nil {
+			handleError(c, "getting rfd revisions", err)
+			return
+		}
+
+		transitions, err = core.GetRFDStateTransitions(rfd.ID)
+		if err != nil {
+			handleError(c, "getting rfd state transitions", err)
+			return
+		}
+
+		merges, err = core.GetRFDMerges(rfd.ID)
+		if err != nil {
+			handleError(c, "getting rfd merges", err)
+			return
+		}
+	}
+
+	relations, err := core.GetRFDRelations(rfd.ID, requestViewer(c))
+	if err != nil {
+		handleError(c, "getting rfd relations", err)
+		return
+	}
+
 	content := template.HTML(rfd.Content)
 	c.HTML(http.StatusOK, "rfd.tmpl", gin.H{
 		"siteName":     config.Config.Site.Name,
 		"rfd":          rfd,
 		"content":      content,
+		"relations":    relations,
+		"revisions":    revisions,
+		"transitions":  transitions,
+		"merges":       merges,
+		"mainBranch":   config.Config.Repo.MainBranch,
+		"nextStates":   core.GetNextRFDStates(rfd),
+		"canEdit":      requestRole(c).Allows(models.RoleAuthor),
+		"stateError":   c.Query("stateError"),
 		"isLoggedIn":   loggedIn,
 		"isPublicView": isPublicView,
+		"csrfToken":    c.GetString("csrfToken"),
+	})
+}
+
+// RFDStatePageHandler moves an RFD to the state picked on the RFD page
+func RFDStatePageHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	var payload models.RFDStatePayload
+	if err := c.ShouldBind(&payload); err != nil {
+		c.Redirect(http.StatusSeeOther, "/"+url.PathEscape(id))
+		return
+	}
+
+	rfd, err := core.ChangeRFDState(id, payload.State, requestActor(c), requestViewer(c))
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidState) || errors.Is(err, core.ErrIllegalStateTransition) || errors.Is(err, core.ErrMergeConflict) {
+			c.Redirect(http.StatusSeeOther, fmt.Sprintf("/%s?stateError=%s", url.PathEscape(id), url.QueryEscape(err.Error())))
+			return
+		}
+
+		handleError(c, "changing rfd state", err)
+		return
+	}
+
+	if rfd == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/"+rfd.ID)
+}
+
+// RFDEditPageHandler shows the in-browser editor for an RFD
+func RFDEditPageHandler(c *gin.Context) {
+	draft, err := core.GetRFDEditDraft(c.Param("id"))
+	if err != nil {
+		handleError(c, "loading rfd for editing", err)
+		return
+	}
+
+	if draft == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	renderRFDEditPage(c, http.StatusOK, draft, "")
+}
+
+// RFDEditSaveHandler commits the editor's contents to the RFD's branch
+func RFDEditSaveHandler(c *gin.Context) {
+	var edit models.RFDEditPayload
+	if err := c.ShouldBind(&edit); err != nil {
+		renderRFDEditPage(c, http.StatusBadRequest, &edit, "Title and authors are required.")
+		return
+	}
+
+	rfd, err := core.EditRFD(c.Param("id"), &edit, c.GetString("userName"), c.GetString("userEmail"))
+	if err != nil {
+		if errors.Is(err, core.ErrEditConflict) {
+			renderRFDEditPage(c, http.StatusConflict, &edit, "Someone else changed this RFD since you started editing. Copy your changes, then reload the editor to start from the latest version.")
+			return
+		}
+
+		handleError(c, "saving rfd edit", err)
+		return
+	}
+
+	if rfd == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/"+rfd.ID)
+}
+
+// RFDPreviewHandler renders the editor's contents for the live preview
+func RFDPreviewHandler(c *gin.Context) {
+	var edit models.RFDEditPayload
+	// Half written forms are fine to preview, so validation errors are ignored
+	_ = c.ShouldBind(&edit)
+
+	content, err := core.PreviewRFDEdit(c.Param("id"), &edit)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "content": content})
+}
+
+func renderRFDEditPage(c *gin.Context, status int, draft *models.RFDEditPayload, editError string) {
+	id := c.Param("id")
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	c.HTML(status, "rfdEdit.tmpl", gin.H{
+		"siteName":  config.Config.Site.Name,
+		"rfdID":     id,
+		"draft":     draft,
+		"editError": editError,
+		"csrfToken": c.GetString("csrfToken"),
+	})
+}
+
+// RFDDiffPageHandler shows what changed between two revisions of an RFD
+// Use ?from=<revision>&to=<revision>, by default the latest revision is compared to the one before it
+func RFDDiffPageHandler(c *gin.Context) {
+	from, err := strconv.ParseInt(c.DefaultQuery("from", "0"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	to, err := strconv.ParseInt(c.DefaultQuery("to", "0"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	rfd, err := core.GetRFDByID(c.Param("id"), requestViewer(c))
+	if err != nil {
+		handleError(c, "getting rfd by id", err)
+		return
+	}
+
+	if rfd == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	revisionDiff, err := core.GetRFDRevisionDiff(rfd.ID, from, to)
+	if err != nil {
+		handleError(c, "diffing rfd revisions", err)
+		return
+	}
+
+	if revisionDiff == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.HTML(http.StatusOK, "rfdDiff.tmpl", gin.H{
+		"siteName":   config.Config.Site.Name,
+		"rfd":        rfd,
+		"from":       revisionDiff.From,
+		"to":         revisionDiff.To,
+		"changes":    revisionDiff.Changes,
+		"content":    diff.HTML(revisionDiff.Content),
+		"hasContent": diff.HasChanges(revisionDiff.Content),
+		"isLoggedIn": true,
+	})
+}
+
+// RepoWritesPageHandler shows repo writes that are still queued or gave up, so failures don't go unnoticed
+func RepoWritesPageHandler(c *gin.Context) {
+	failed, err := core.GetRepoWrites(models.RepoWriteFailed)
+	if err != nil {
+		handleError(c, "getting failed repo writes", err)
+		return
+	}
+
+	pending, err := core.GetRepoWrites(models.RepoWritePending)
+	if err != nil {
+		handleError(c, "getting pending repo writes", err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "adminRepoWrites.tmpl", gin.H{
+		"siteName":   config.Config.Site.Name,
+		"failed":     failed,
+		"pending":    pending,
+		"isLoggedIn": true,
+		"csrfToken":  c.GetString("csrfToken"),
+	})
+}
+
+// RepoWriteRetryHandler puts a failed repo write back in the queue
+func RepoWriteRetryHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	write, err := core.RetryRepoWrite(id)
+	if err != nil {
+		handleError(c, "retrying repo write", err)
+		return
+	}
+
+	if write == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/admin/repo-writes")
+}
+
+// APITokensPageHandler lists the signed in user's API tokens
+func APITokensPageHandler(c *gin.Context) {
+	renderAPITokensPage(c, http.StatusOK, "", "")
+}
+
+// APITokenCreateHandler mints an API token for the signed in user and shows it, the only time it can be seen
+func APITokenCreateHandler(c *gin.Context) {
+	var payload models.APITokenPayload
+	if err := c.ShouldBind(&payload); err != nil {
+		renderAPITokensPage(c, http.StatusBadRequest, "", "A name and at least one scope are required")
+		return
+	}
+
+	_, raw, err := core.CreateAPIToken(&payload, c.GetString("userEmail"), requestViewer(c).Groups)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidAPIToken) {
+			renderAPITokensPage(c, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		handleError(c, "creating api token", err)
+		return
+	}
+
+	renderAPITokensPage(c, http.StatusOK, raw, "")
+}
+
+// APITokenRevokeHandler revokes one of the signed in user's API tokens
+func APITokenRevokeHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	token, err := core.RevokeAPIToken(id, c.GetString("userEmail"))
+	if err != nil {
+		handleError(c, "revoking api token", err)
+		return
+	}
+
+	if token == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/tokens")
+}
+
+func renderAPITokensPage(c *gin.Context, status int, newToken string, formError string) {
+	tokens, err := core.GetAPITokens(c.GetString("userEmail"))
+	if err != nil {
+		handleError(c, "getting api tokens", err)
+		return
+	}
+
+	c.HTML(status, "apiTokens.tmpl", gin.H{
+		"siteName":   config.Config.Site.Name,
+		"tokens":     tokens,
+		"scopes":     models.ScopesForRole(requestRole(c)), // Only offer the scopes the user's role allows
+		"newToken":   newToken,
+		"formError":  formError,
+		"now":        time.Now(),
+		"isLoggedIn": true,
+		"csrfToken":  c.GetString("csrfToken"),
 	})
 }
 
 // RFDListPageHandler gets all RFDs (authenticated only)
 func RFDListPageHandler(c *gin.Context) {
-	rfds, err := core.GetRFDs()
+	rfds, err := core.GetRFDs(requestViewer(c))
 	if err != nil {
 		handleErrorJSON(c, "getting rfds", err)
 		return
@@ -185,26 +510,95 @@ func RFDListPageHandler(c *gin.Context) {
 		"siteName":     config.Config.Site.Name,
 		"rfds":         rfds,
 		"isLoggedIn":   true,
+		"canCreate":    requestRole(c).Allows(models.RoleAuthor),
 		"isPublicView": false,
 	})
 }
 
+// RFDFileHandler serves an attachment stored alongside an RFD, such as an image its README.md links to
+func RFDFileHandler(c *gin.Context) {
+	file, err := core.GetRFDFile(c.Param("id"), c.Param("path"))
+	if err != nil {
+		handleErrorJSON(c, "getting rfd file", err)
+		return
+	}
+
+	if file == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, file.SHA)
+
+	// Private because non-public RFDs' files need a session
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", etag)
+
+	// Files are whatever was committed, don't let HTML or SVG run scripts on the site
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src 'self' data:; style-src 'unsafe-inline'; sandbox")
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
 // RFDCreatePageHandler Returns UI for creating RFD
 func RFDCreatePageHandler(c *gin.Context) {
-	c.HTML(http.StatusOK, "rfdCreate.tmpl", gin.H{"siteName": config.Config.Site.Name})
+	renderRFDCreatePage(c, http.StatusOK, "")
 }
 
-// RFDCreatedPageHandler Returns UI for creating RFD
-func RFDCreatedPageHandler(c *gin.Context) {
-	rfdNum := c.Query("rfd")
+// RFDCreateSaveHandler creates the RFD from the create form
+func RFDCreateSaveHandler(c *gin.Context) {
+	var createPayload models.RFDCreatePayload
+	if err := c.ShouldBind(&createPayload); err != nil {
+		renderRFDCreatePage(c, http.StatusBadRequest, "Title and authors are required.")
+		return
+	}
 
-	devLink, err := core.GetRFDCodespaceLink(rfdNum)
+	rfd, err := core.CreateRFD(&createPayload, requestActor(c))
 	if err != nil {
-		handleErrorJSON(c, "getting rfds", err)
+		if errors.Is(err, core.ErrTemplateNotFound) {
+			renderRFDCreatePage(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		handleError(c, "creating rfd", err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, fmt.Sprintf("/created?rfd=%s", rfd.ID))
+}
+
+func renderRFDCreatePage(c *gin.Context, status int, createError string) {
+	templates, err := core.GetRFDTemplates()
+	if err != nil {
+		handleError(c, "getting templates", err)
 		return
 	}
 
-	c.HTML(http.StatusOK, "rfdCreated.tmpl", gin.H{"siteName": config.Config.Site.Name, "repo": config.Config.Repo.URL, "rfdNum": rfdNum, "githubDevLink": devLink})
+	c.HTML(status, "rfdCreate.tmpl", gin.H{
+		"siteName":    config.Config.Site.Name,
+		"templates":   templates,
+		"createError": createError,
+		"csrfToken":   c.GetString("csrfToken"),
+	})
+}
+
+// RFDCreatedPageHandler Returns UI for creating RFD
+func RFDCreatedPageHandler(c *gin.Context) {
+	rfdNum := c.Query("rfd")
+
+	c.HTML(http.StatusOK, "rfdCreated.tmpl", gin.H{
+		"siteName":       config.Config.Site.Name,
+		"repo":           config.Config.Repo.URL,
+		"rfdNum":         rfdNum,
+		"editOnlineLink": core.GetRFDEditOnlineLink(rfdNum),
+		"branchLink":     core.GetRFDBranchLink(rfdNum),
+	})
 }
 
 func ServeLogoSVGHandler(c *gin.Context) {
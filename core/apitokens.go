@@ -0,0 +1,226 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// ErrInvalidAPIToken is returned when a token can't be created as asked
+var ErrInvalidAPIToken = errors.New("invalid api token")
+
+// apiTokenPrefix starts every API token, so they're easy to spot in logs and secret scanners
+const apiTokenPrefix = "rfd_"
+
+// apiTokenTouchInterval is how stale a token's last used time may get, so using it doesn't write on every request
+const apiTokenTouchInterval = time.Minute
+
+// maxAPITokenDays is the longest a token can be made to last, 0 still means it never expires
+const maxAPITokenDays = 365
+
+// apiTokenGroupsMaxAge is how long a token keeps working without its owner signing in, which
+// is when their groups are checked. Someone removed from the identity provider can't sign in,
+// so their tokens stop working after this.
+const apiTokenGroupsMaxAge = 30 * 24 * time.Hour
+
+// CreateAPIToken mints a token for owner. The token itself is only returned
+// here, just its hash is stored so it can't be shown again.
+func CreateAPIToken(payload *models.APITokenPayload, owner string, ownerGroups []string) (*models.APIToken, string, error) {
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	}
+
+	if payload.ExpiresInDays < 0 || payload.ExpiresInDays > maxAPITokenDays {
+		return nil, "", fmt.Errorf("%w: tokens can last at most %d days", ErrInvalidAPIToken, maxAPITokenDays)
+	}
+
+	role := config.Config.Roles.RoleForGroups(ownerGroups)
+
+	scopes := models.APITokenScopes{}
+	for _, s := range payload.Scopes {
+		scope := models.APITokenScope(s)
+		if !scope.Valid() {
+			return nil, "", fmt.Errorf("%w: unknown scope %s", ErrInvalidAPIToken, s)
+		}
+
+		if !role.Allows(scope.Role()) {
+			return nil, "", fmt.Errorf("%w: the %s scope requires the %s role", ErrInvalidAPIToken, scope, scope.Role())
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIToken)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	raw := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	if ownerGroups == nil {
+		ownerGroups = []string{}
+	}
+
+	token := &models.APIToken{
+		Name:            name,
+		Owner:           owner,
+		OwnerGroups:     ownerGroups,
+		GroupsCheckedAt: time.Now(),
+		Prefix:          raw[:len(apiTokenPrefix)+6],
+		Hash:            hashAPIToken(raw),
+		Scopes:          scopes,
+	}
+
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := _dataStore.CreateAPIToken(token); err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("API token %s (%s) created by %s", token.Prefix, token.Name, owner)
+
+	return token, raw, nil
+}
+
+// AuthenticateAPIToken returns the token raw is, nil if it isn't one, has expired or been
+// revoked, or its owner hasn't signed in for too long to know their groups
+func AuthenticateAPIToken(raw string) (*models.APIToken, error) {
+	if !strings.HasPrefix(raw, apiTokenPrefix) {
+		return nil, nil
+	}
+
+	token, err := _dataStore.GetAPITokenByHash(hashAPIToken(raw))
+	if err != nil || token == nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, nil
+	}
+
+	if now.Sub(token.GroupsCheckedAt) > apiTokenGroupsMaxAge {
+		log.Printf("API token %s refused, %s hasn't signed in since %s", token.Prefix, token.Owner, token.GroupsCheckedAt.Format(time.RFC3339))
+		return nil, nil
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval {
+		if err := _dataStore.TouchAPIToken(token.ID, now); err != nil {
+			log.Printf("Failed to record use of API token %s: %v", token.Prefix, err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+// APITokenScopes returns what token can do right now: its scopes, less any its owner's role doesn't allow
+func APITokenScopes(token *models.APIToken) models.APITokenScopes {
+	role := config.Config.Roles.RoleForGroups(token.OwnerGroups)
+
+	scopes := models.APITokenScopes{}
+	for _, scope := range token.Scopes {
+		if role.Allows(scope.Role()) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
+// CheckAPITokenGroups is called when owner signs in with groups. Their tokens are revoked if
+// they've left any of the groups the token was created with, since those decide its role and
+// which RFDs it can see. The rest keep working for another apiTokenGroupsMaxAge.
+func CheckAPITokenGroups(owner string, groups []string) error {
+	tokens, err := _dataStore.GetAPITokens(owner)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		current[strings.ToLower(group)] = true
+	}
+
+	now := time.Now()
+	for _, token := range tokens {
+		if !token.Active(now) {
+			continue
+		}
+
+		left := []string{}
+		for _, group := range token.OwnerGroups {
+			if !current[strings.ToLower(group)] {
+				left = append(left, group)
+			}
+		}
+
+		if len(left) > 0 {
+			if err := _dataStore.RevokeAPIToken(token.ID, now); err != nil {
+				return err
+			}
+
+			log.Printf("API token %s (%s) revoked, %s is no longer in %s", token.Prefix, token.Name, owner, strings.Join(left, ", "))
+			continue
+		}
+
+		if err := _dataStore.MarkAPITokenGroupsChecked(token.ID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAPITokens returns owner's tokens, newest first
+func GetAPITokens(owner string) ([]models.APIToken, error) {
+	return _dataStore.GetAPITokens(owner)
+}
+
+// RevokeAPIToken revokes one of owner's tokens. Returns nil if owner has no token with id.
+func RevokeAPIToken(id int64, owner string) (*models.APIToken, error) {
+	token, err := _dataStore.GetAPIToken(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil || token.Owner != owner {
+		return nil, nil
+	}
+
+	if token.RevokedAt != nil {
+		return token, nil
+	}
+
+	now := time.Now()
+	if err := _dataStore.RevokeAPIToken(id, now); err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+
+	log.Printf("API token %s (%s) revoked by %s", token.Prefix, token.Name, owner)
+
+	return token, nil
+}
+
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
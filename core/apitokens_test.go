@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestCheckAPITokenGroups(t *testing.T) {
+	newTestDataStore(t)
+
+	owner := "member@example.com"
+	payload := &models.APITokenPayload{Name: "CI", Scopes: []string{string(models.ScopeRFDsRead)}}
+
+	_, securityRaw, err := CreateAPIToken(payload, owner, []string{"engineering", "security-team"})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	_, engineeringRaw, err := CreateAPIToken(payload, owner, []string{"engineering"})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	// Signing in after leaving security-team
+	if err := CheckAPITokenGroups(owner, []string{"Engineering"}); err != nil {
+		t.Fatalf("Failed to check tokens: %v", err)
+	}
+
+	if token, err := AuthenticateAPIToken(securityRaw); err != nil || token != nil {
+		t.Errorf("Expected the token made in security-team to be revoked, got %+v, %v", token, err)
+	}
+
+	token, err := AuthenticateAPIToken(engineeringRaw)
+	if err != nil || token == nil {
+		t.Fatalf("Expected the engineering token to keep working, got %+v, %v", token, err)
+	}
+
+	// The owner hasn't signed in for too long to trust their groups
+	if err := _dataStore.MarkAPITokenGroupsChecked(token.ID, time.Now().Add(-apiTokenGroupsMaxAge-time.Hour)); err != nil {
+		t.Fatalf("Failed to age token: %v", err)
+	}
+
+	if token, err := AuthenticateAPIToken(engineeringRaw); err != nil || token != nil {
+		t.Errorf("Expected a token whose owner hasn't signed in lately to be refused, got %+v, %v", token, err)
+	}
+
+	// Signing in again brings it back
+	if err := CheckAPITokenGroups(owner, []string{"engineering"}); err != nil {
+		t.Fatalf("Failed to check tokens: %v", err)
+	}
+
+	if token, err := AuthenticateAPIToken(engineeringRaw); err != nil || token == nil {
+		t.Errorf("Expected the token to work after signing in, got %+v, %v", token, err)
+	}
+}
@@ -3,19 +3,21 @@ package core
 import (
 	"context"
 	"crypto/rsa"
+	"errors"
 	"fmt"
-	"net/url"
-	"os"
+	"log"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/forge"
+	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/geekgonecrazy/rfd-tool/store"
+	"github.com/geekgonecrazy/rfd-tool/store/postgresstore"
 	"github.com/geekgonecrazy/rfd-tool/store/sqlitestore"
 	"github.com/geekgonecrazy/rfd-tool/webhook"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/github"
@@ -31,18 +33,12 @@ var _jwtPublicKey *rsa.PublicKey
 
 var _validId *regexp.Regexp
 
-var _gitPublicKeys *ssh.PublicKeys
-var _gitCloneOptions *git.CloneOptions
-
 var _webhookClient *webhook.Client
 
-func Setup() error {
-	_validId, _ = regexp.Compile(`^\d{1,4}$`)
-
-	// Initialize datastore based on config
-	var dataStore store.Store
-	var err error
+var _forge forge.Forge
 
+// openDataStore initializes the datastore based on config
+func openDataStore() (store.Store, error) {
 	storeType := config.Config.Store
 	if storeType == "" {
 		storeType = "sqlite" // default to sqlite
@@ -50,21 +46,59 @@ func Setup() error {
 
 	switch storeType {
 	case "sqlite":
-		dataStore, err = sqlitestore.New()
+		return sqlitestore.New()
+	case "postgres":
+		return postgresstore.New()
 	default:
-		return fmt.Errorf("unknown store type: %s (valid option: sqlite)", storeType)
+		return nil, fmt.Errorf("unknown store type: %s (valid options: sqlite, postgres)", storeType)
 	}
+}
 
+// MigrateUp applies pending migrations without setting up the rest of core
+func MigrateUp() error {
+	dataStore, err := openDataStore()
 	if err != nil {
 		return err
 	}
 
+	return dataStore.Migrate()
+}
+
+// GetMigrationStatus lists the known migrations and whether they've been applied
+func GetMigrationStatus() ([]models.MigrationStatus, error) {
+	dataStore, err := openDataStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return dataStore.MigrationStatus()
+}
+
+func Setup() error {
+	_validId, _ = regexp.Compile(`^\d{1,4}$`)
+
+	dataStore, err := openDataStore()
+	if err != nil {
+		return err
+	}
+
+	// Migrations are applied automatically on startup
+	if err := dataStore.Migrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
 	_dataStore = dataStore
 
 	if err := _dataStore.EnsureUpdateLatestRFDID(); err != nil {
 		return err
 	}
 
+	if config.Config.APISecret != "" && !config.Config.LegacyAPISecret {
+		log.Println("apiSecret is ignored without legacyApiSecret, the API only accepts personal API tokens")
+	} else if config.Config.LegacyAPISecret {
+		log.Println("legacyApiSecret is set, the shared apiSecret has full API access until it's replaced by personal API tokens")
+	}
+
 	_githubOAuth = &oauth2.Config{
 		ClientID:     config.Config.Github.ClientID,
 		ClientSecret: config.Config.Github.ClientSecret,
@@ -76,7 +110,7 @@ func Setup() error {
 	_oidcOAuth = &oauth2.Config{
 		ClientID:     config.Config.OIDC.ClientID,
 		ClientSecret: config.Config.OIDC.ClientSecret,
-		Scopes:       []string{"openid", "profile", "email"},
+		Scopes:       config.Config.OIDCScopes(),
 		RedirectURL:  fmt.Sprintf("%s/oidc/callback", config.Config.Site.URL),
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  config.Config.OIDC.AuthURL,
@@ -105,27 +139,42 @@ func Setup() error {
 
 	_jwtPublicKey = publicKey
 
-	publicKeys, err := ssh.NewPublicKeys(config.Config.Repo.Username, []byte(config.Config.Repo.PrivateDeployKey), "")
+	// Setup the git stuff
+	repoForge, err := forge.New(forge.Options{
+		Type:    config.Config.ForgeType(),
+		RepoURL: config.Config.Repo.URL,
+		APIURL:  config.Config.Forge.APIURL,
+		Token:   config.Config.Forge.Token,
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to set up forge: %w", err)
 	}
 
-	// Setup the git stuff
-	_gitPublicKeys = publicKeys
+	_forge = repoForge
 
-	u, err := url.Parse(config.Config.Repo.URL)
+	// repo.auth.type is validated by config, the values match forge's protocols
+	protocol := forge.Protocol(config.Config.Repo.AuthType())
+
+	// A repo on disk needs no key
+	if protocol == forge.ProtocolSSH && config.Config.ForgeType() != forge.TypeGit && strings.TrimSpace(config.Config.Repo.SSHPrivateKey()) == "" {
+		return errors.New("repo.privateDeployKey (or repo.auth.privateKey) is required for ssh")
+	}
+
+	gitAuth, err := _forge.Auth(protocol, forge.Credentials{
+		SSHUsername:   config.Config.Repo.SSHUsername(),
+		SSHPrivateKey: config.Config.Repo.SSHPrivateKey(),
+		Username:      config.Config.Repo.Auth.Username,
+		Token:         config.Config.Repo.Auth.Token,
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid repo.auth: %w", err)
 	}
 
-	sshGithubURL := fmt.Sprintf("%s:%s.git", u.Host, u.Path)
+	_repoCache = newRepoCache(filepath.Join(config.Config.DataPath, repoCacheName), _forge.CloneURL(protocol), gitAuth, config.Config.Repo.MainBranch)
 
-	_gitCloneOptions = &git.CloneOptions{
-		URL:           sshGithubURL,
-		Progress:      os.Stdout,
-		SingleBranch:  true,
-		ReferenceName: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", config.Config.Repo.MainBranch)),
-		Auth:          publicKeys,
+	// Fail now rather than on the first RFD created if the credentials are wrong
+	if err := _repoCache.checkAccess(); err != nil {
+		return err
 	}
 
 	// Initialize webhook client if configured
@@ -0,0 +1,16 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// CSRFToken is the token writes made with the session cookie have to send back. It's
+// tied to the session, so it changes when the user signs in again, and it can't be
+// worked out without the JWT private key.
+func CSRFToken(sessionToken string) string {
+	mac := hmac.New(sha256.New, _jwtPrivateKey.D.Bytes())
+	mac.Write([]byte("csrf:" + sessionToken))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/adrg/frontmatter"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/renderer"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GetRFDEditDraft loads an RFD's README.md from its branch (or main) to fill the editor
+func GetRFDEditDraft(id string) (*models.RFDEditPayload, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	sources, err := fetchRFDSources()
+	if err != nil {
+		return nil, err
+	}
+
+	source, ok := sources[id]
+	if !ok {
+		return nil, nil
+	}
+
+	var rfdMeta models.RFDMetaYAML
+	body, err := frontmatter.Parse(bytes.NewReader(source.Content), &rfdMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+
+	return &models.RFDEditPayload{
+		Title:      rfdMeta.Title,
+		Authors:    strings.Join(rfdMeta.Authors, ", "),
+		Tags:       strings.Join(rfdMeta.Tags, ", "),
+		Public:     rfdMeta.Public,
+		Body:       string(body),
+		BaseCommit: source.Commit.String(),
+	}, nil
+}
+
+// PreviewRFDEdit renders the editor's contents without saving anything
+func PreviewRFDEdit(id string, edit *models.RFDEditPayload) (string, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	rfdMeta := models.RFDMetaYAML{}
+	applyRFDEdit(&rfdMeta, edit)
+
+	rfdFile, err := buildRFDFile(rfdMeta, []byte(normalizeNewlines(edit.Body)))
+	if err != nil {
+		return "", err
+	}
+
+	rendered, err := renderer.RenderRFD(id, bytes.NewReader(rfdFile))
+	if err != nil {
+		return "", err
+	}
+
+	return rendered.Content, nil
+}
+
+// EditRFD commits the editor's contents to the RFD's branch as the signed in user.
+// Returns ErrEditConflict if README.md changed since edit.BaseCommit.
+func EditRFD(id string, edit *models.RFDEditPayload, editorName string, editorEmail string) (*models.RFD, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	if edit.BaseCommit == "" {
+		return nil, errors.New("no base commit provided")
+	}
+
+	existing, err := _dataStore.GetRFDByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	if editorName == "" {
+		editorName = editorEmail
+	}
+
+	commitMsg := strings.TrimSpace(edit.Message)
+	if commitMsg == "" {
+		commitMsg = fmt.Sprintf("Edit RFD %s", id)
+	}
+
+	commit, err := commitRFDChange(id, rfdChange{
+		Message:      commitMsg,
+		Author:       &object.Signature{Name: editorName, Email: editorEmail, When: time.Now()},
+		BaseCommit:   edit.BaseCommit,
+		CreateBranch: true,
+		Update: func(rfdMeta *models.RFDMetaYAML, body []byte) []byte {
+			applyRFDEdit(rfdMeta, edit)
+			return []byte(normalizeNewlines(edit.Body))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderer.RenderRFD(id, bytes.NewReader(commit.File))
+	if err != nil {
+		return nil, err
+	}
+
+	// The discussion link may still be on its way into git, don't wipe it out
+	if rendered.Discussion == "" {
+		rendered.Discussion = existing.Discussion
+	}
+
+	if err := CreateOrUpdateRFD(rendered, false, &models.ChangeSource{Actor: editorEmail, CommitSHA: commit.Commit.String()}); err != nil {
+		return nil, err
+	}
+
+	log.Printf("RFD %s edited by %s (%s)", id, editorEmail, commit.Commit.String()[:7])
+
+	return _dataStore.GetRFDByID(id)
+}
+
+// applyRFDEdit copies the editable fields onto the frontmatter, leaving state and discussion alone
+func applyRFDEdit(rfdMeta *models.RFDMetaYAML, edit *models.RFDEditPayload) {
+	rfdMeta.Title = strings.TrimSpace(edit.Title)
+	rfdMeta.Authors = splitList(edit.Authors)
+	rfdMeta.Tags = splitList(edit.Tags)
+	rfdMeta.Public = edit.Public
+}
+
+// splitList splits a comma separated form field
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// normalizeNewlines undoes the \r\n browsers send for textareas
+func normalizeNewlines(text string) string {
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func testRFDEdit(baseCommit plumbing.Hash) *models.RFDEditPayload {
+	edit := &models.RFDEditPayload{
+		Title:   "Replication, revised",
+		Authors: "Jane Doe <jane@example.com>",
+		Body:    "Revised body.\r\n",
+	}
+
+	if !baseCommit.IsZero() {
+		edit.BaseCommit = baseCommit.String()
+	}
+
+	return edit
+}
+
+func TestEditRFD(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	head := pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+
+	rfd, err := EditRFD("1", testRFDEdit(head), "Jane Doe", "jane@example.com")
+	if err != nil {
+		t.Fatalf("Failed to edit: %v", err)
+	}
+
+	if rfd == nil || rfd.Title != "Replication, revised" {
+		t.Errorf("Expected the stored RFD to be updated, got %+v", rfd)
+	}
+
+	commit, err := remote.CommitObject(branchHash(t, remote, plumbing.NewBranchReferenceName("0001")))
+	if err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != head {
+		t.Errorf("Expected the edit to be committed on top of %s, got %v", head, commit.ParentHashes)
+	}
+
+	if commit.Author.Name != "Jane Doe" || commit.Author.Email != "jane@example.com" {
+		t.Errorf("Expected the edit to be authored by the signed in user, got %s <%s>", commit.Author.Name, commit.Author.Email)
+	}
+
+	if commit.Committer.Email != "rfd-tool@example.com" {
+		t.Errorf("Expected the edit to be committed by the tool, got %s", commit.Committer.Email)
+	}
+
+	content := remoteFile(t, remote, "0001", "rfds/0001/README.md")
+	if !strings.Contains(content, "title: Replication, revised") || !strings.HasSuffix(content, "---\nRevised body.\n") {
+		t.Errorf("Expected the edit in README.md, got %q", content)
+	}
+
+	if !strings.Contains(content, "state: discussion") {
+		t.Errorf("Expected the edit to leave the state alone, got %q", content)
+	}
+}
+
+func TestEditRFDStaleBaseCommit(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	base := pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+	head := pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication, edited elsewhere", models.Discussion))
+
+	rfd, err := EditRFD("0001", testRFDEdit(base), "Jane Doe", "jane@example.com")
+	if !errors.Is(err, ErrEditConflict) {
+		t.Fatalf("Expected an edit conflict, got %+v, %v", rfd, err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("0001")); got != head {
+		t.Errorf("Expected remote 0001 left at %s, got %s", head, got)
+	}
+
+	stored, err := _dataStore.GetRFDByID("0001")
+	if err != nil {
+		t.Fatalf("Failed to get RFD: %v", err)
+	}
+
+	if stored.Title != "Replication" {
+		t.Errorf("Expected the stored RFD to be left alone, got %q", stored.Title)
+	}
+}
+
+func TestEditRFDCreatesBranch(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0002")
+	remotePath, remote := useTestRemote(t)
+
+	mainHead := pushTestFile(t, remotePath, "main", "rfds/0002/README.md", testRFDFile("Replication", models.Discussion))
+
+	if _, err := EditRFD("0002", testRFDEdit(mainHead), "Jane Doe", "jane@example.com"); err != nil {
+		t.Fatalf("Failed to edit: %v", err)
+	}
+
+	branch := branchHash(t, remote, plumbing.NewBranchReferenceName("0002"))
+	commit, err := remote.CommitObject(branch)
+	if err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != mainHead {
+		t.Errorf("Expected the branch to start from main at %s, got %v", mainHead, commit.ParentHashes)
+	}
+
+	if content := remoteFile(t, remote, "0002", "rfds/0002/README.md"); !strings.Contains(content, "title: Replication, revised") {
+		t.Errorf("Expected the edit on the new branch, got %q", content)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != mainHead {
+		t.Errorf("Expected remote main left at %s, got %s", mainHead, got)
+	}
+}
+
+func TestEditRFDNeedsBaseCommit(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	head := pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+
+	if rfd, err := EditRFD("0001", testRFDEdit(plumbing.ZeroHash), "Jane Doe", "jane@example.com"); err == nil {
+		t.Fatalf("Expected an edit without a base commit to be refused, got %+v", rfd)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("0001")); got != head {
+		t.Errorf("Expected remote 0001 left at %s, got %s", head, got)
+	}
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// MaxRFDFileSize is the largest attachment stored, bigger files in the repo are skipped
+const MaxRFDFileSize = 10 << 20
+
+// ErrInvalidFilePath is returned for attachment paths that leave the RFD's folder or are markdown
+var ErrInvalidFilePath = errors.New("invalid file path")
+
+// GetRFDFile returns one of an RFD's attachments, nil if it has none at filePath
+func GetRFDFile(id string, filePath string) (*models.RFDFile, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	filePath, err := cleanRFDFilePath(filePath)
+	if err != nil {
+		return nil, nil
+	}
+
+	return _dataStore.GetRFDFile(id, filePath)
+}
+
+// GetRFDFiles lists an RFD's attachments, without their content
+func GetRFDFiles(id string) ([]models.RFDFile, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	return _dataStore.GetRFDFiles(id)
+}
+
+// SaveRFDFile stores an attachment uploaded over the API, such as by rfd-client when importing.
+// Returns nil if there's no RFD with id that viewer can see.
+func SaveRFDFile(id string, filePath string, content []byte, actor string, viewer models.Viewer) (*models.RFDFile, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	filePath, err := cleanRFDFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > MaxRFDFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", filePath, MaxRFDFileSize)
+	}
+
+	rfd, err := _dataStore.GetRFDByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if rfd == nil || !rfd.VisibleTo(viewer) {
+		return nil, nil
+	}
+
+	file := newRFDFile(id, filePath, content, plumbing.ComputeHash(plumbing.BlobObject, content))
+	if err := _dataStore.SaveRFDFile(file); err != nil {
+		return nil, err
+	}
+
+	log.Printf("RFD %s file %s uploaded by %s", id, filePath, actor)
+
+	return file, nil
+}
+
+func newRFDFile(rfdNum string, filePath string, content []byte, hash plumbing.Hash) *models.RFDFile {
+	contentType := mime.TypeByExtension(path.Ext(filePath))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	return &models.RFDFile{
+		RFDID:       rfdNum,
+		Path:        filePath,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA:         hash.String(),
+		Content:     content,
+	}
+}
+
+// cleanRFDFilePath cleans a path relative to an RFD's folder, refusing ones that
+// lead out of it and markdown files, which are rendered rather than served
+func cleanRFDFilePath(filePath string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(filePath, "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || isMarkdownFile(cleaned) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidFilePath, filePath)
+	}
+
+	return cleaned, nil
+}
+
+func isMarkdownFile(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), ".md")
+}
+
+// rfdFolderFiles lists the attachments in an RFD's folder at commit, every file
+// but markdown, with their blob hashes, by path relative to the folder
+func rfdFolderFiles(commit *object.Commit, rfdNum string) (map[string]plumbing.Hash, error) {
+	files := map[string]plumbing.Hash{}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	folderTree, err := tree.Tree(path.Join(config.Config.Repo.Folder, rfdNum))
+	if err == object.ErrDirectoryNotFound {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(folderTree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Mode.IsFile() && !isMarkdownFile(name) {
+			files[name] = entry.Hash
+		}
+	}
+
+	return files, nil
+}
+
+// syncRFDFiles makes the stored attachments match files from the repo, only
+// reading the ones that changed. Returns whether anything was stored or removed.
+func syncRFDFiles(rfdNum string, files map[string]plumbing.Hash) (bool, error) {
+	stored, err := _dataStore.GetRFDFiles(rfdNum)
+	if err != nil {
+		return false, err
+	}
+
+	storedSHAs := map[string]string{}
+	for _, file := range stored {
+		storedSHAs[file.Path] = file.SHA
+	}
+
+	changed := false
+	for filePath, hash := range files {
+		if storedSHAs[filePath] == hash.String() {
+			continue
+		}
+
+		content, err := _repoCache.readBlob(hash, MaxRFDFileSize)
+		if err == errBlobTooLarge {
+			log.Printf("Skipping %s in RFD %s, it's larger than %d bytes", filePath, rfdNum, MaxRFDFileSize)
+			continue
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+
+		log.Printf("Storing %s for RFD %s", filePath, rfdNum)
+		if err := _dataStore.SaveRFDFile(newRFDFile(rfdNum, filePath, content, hash)); err != nil {
+			return changed, err
+		}
+
+		changed = true
+	}
+
+	for filePath := range storedSHAs {
+		if _, ok := files[filePath]; ok {
+			continue
+		}
+
+		log.Printf("Removing %s from RFD %s, it's no longer in the repo", filePath, rfdNum)
+		if err := _dataStore.DeleteRFDFile(rfdNum, filePath); err != nil {
+			return changed, err
+		}
+
+		changed = true
+	}
+
+	return changed, nil
+}
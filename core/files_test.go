@@ -0,0 +1,82 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestCleanRFDFilePath(t *testing.T) {
+	valid := map[string]string{
+		"/diagram.png":         "diagram.png",
+		"images/./flow.svg":    "images/flow.svg",
+		"images/../spec.pdf":   "spec.pdf",
+		"/images//nested.jpeg": "images/nested.jpeg",
+	}
+
+	for input, want := range valid {
+		if got, err := cleanRFDFilePath(input); err != nil || got != want {
+			t.Errorf("Expected %s to clean to %s, got %s, %v", input, want, got, err)
+		}
+	}
+
+	for _, input := range []string{"", "/", "..", "../0002/diagram.png", "images/../../secret", "README.md", "notes.MD"} {
+		if got, err := cleanRFDFilePath(input); err == nil {
+			t.Errorf("Expected %q to be refused, got %s", input, got)
+		}
+	}
+}
+
+func TestRFDFolderFiles(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("dataPath: "+dir+"/\nrepo:\n  folder: rfds\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := config.Load(configFile); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+
+	worktree, _ := r.Worktree()
+	commitTestFile(t, worktree, "rfds/0001/README.md", "# RFD")
+	commitTestFile(t, worktree, "rfds/0001/diagram.png", "png")
+	commitTestFile(t, worktree, "rfds/0001/images/flow.svg", "svg")
+	commitTestFile(t, worktree, "rfds/0001/notes.md", "# Notes")
+	hash := commitTestFile(t, worktree, "rfds/0002/other.png", "other")
+
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("Failed to load commit: %v", err)
+	}
+
+	files, err := rfdFolderFiles(commit, "0001")
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+
+	want := map[string]plumbing.Hash{
+		"diagram.png":     plumbing.ComputeHash(plumbing.BlobObject, []byte("png")),
+		"images/flow.svg": plumbing.ComputeHash(plumbing.BlobObject, []byte("svg")),
+	}
+
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	if files, err := rfdFolderFiles(commit, "0003"); err != nil || len(files) != 0 {
+		t.Errorf("Expected no files for a missing folder, got %v, %v", files, err)
+	}
+}
@@ -1 +1,42 @@
 package core
+
+import (
+	"log"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/webhook"
+)
+
+// HandleGithubPush works out which RFDs a push touched and re-syncs them in the background.
+// Returns the RFD numbers that will be re-synced.
+func HandleGithubPush(event *webhook.PushEvent) []string {
+	branch := event.Branch()
+	if branch != config.Config.Repo.MainBranch && !_rfdBranch.MatchString(branch) {
+		log.Printf("Ignoring push to %s", event.Ref)
+		return []string{}
+	}
+
+	rfdNums := event.ChangedRFDs(config.Config.Repo.Folder)
+	removed := event.RemovedRFDs(config.Config.Repo.Folder)
+
+	// A deleted RFD branch falls back to the copy on main
+	if event.Deleted && _rfdBranch.MatchString(branch) {
+		rfdNums = []string{branch}
+	}
+
+	if len(rfdNums) == 0 {
+		return rfdNums
+	}
+
+	actor := event.Actor()
+
+	log.Printf("Push to %s by %s changed RFDs %v, re-syncing", branch, actor, rfdNums)
+
+	go func() {
+		if err := SyncRFDs(rfdNums, removed, actor); err != nil {
+			log.Printf("Failed to sync RFDs from push to %s: %v", branch, err)
+		}
+	}()
+
+	return rfdNums
+}
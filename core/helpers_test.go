@@ -0,0 +1,55 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/store/sqlitestore"
+)
+
+func TestMain(m *testing.M) {
+	// Normally set by Setup, which needs OIDC and the RFD repo
+	_validId = regexp.MustCompile(`^\d{1,4}$`)
+
+	os.Exit(m.Run())
+}
+
+// newTestDataStore points core at a fresh sqlite store
+func newTestDataStore(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("dataPath: "+dir+"/\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := config.Load(configFile); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	dataStore, err := sqlitestore.New()
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	if err := dataStore.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate store: %v", err)
+	}
+
+	_dataStore = dataStore
+}
+
+// importTestRFD stores an RFD in discussion, as if it had been synced
+func importTestRFD(t *testing.T, id string) {
+	t.Helper()
+
+	rfd := &models.RFD{ID: id, RFDMeta: models.RFDMeta{Title: "Replication", State: models.Discussion}}
+	if err := _dataStore.ImportRFD(rfd); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrMergeConflict is returned when an RFD's branch and main changed the same files
+var ErrMergeConflict = errors.New("merge conflict")
+
+// mergesIntoMain reports whether moving an RFD to state merges its branch into main
+func mergesIntoMain(state models.RFDState) bool {
+	return state == models.Published || state == models.Committed
+}
+
+// GetRFDMerges returns every merge of an RFD's branch into main, newest first
+func GetRFDMerges(id string) ([]models.RFDMerge, error) {
+	if id == "" {
+		return nil, errors.New("no id provided")
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	return _dataStore.GetRFDMerges(id)
+}
+
+// rfdMergeResult is what mergeRFDBranch did to main
+type rfdMergeResult struct {
+	Commit      plumbing.Hash
+	FastForward bool
+}
+
+// branchMerged reports whether everything on branch is already on main
+func branchMerged(r *git.Repository, branch plumbing.ReferenceName) (bool, error) {
+	branchCommit, mainCommit, err := branchAndMainCommits(r, branch)
+	if err != nil {
+		return false, err
+	}
+
+	if branchCommit.Hash == mainCommit.Hash {
+		return true, nil
+	}
+
+	return branchCommit.IsAncestor(mainCommit)
+}
+
+func branchAndMainCommits(r *git.Repository, branch plumbing.ReferenceName) (*object.Commit, *object.Commit, error) {
+	branchRef, err := r.Reference(branch, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", branch.Short(), err)
+	}
+
+	mainRef, err := r.Reference(plumbing.NewBranchReferenceName(config.Config.Repo.MainBranch), true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", config.Config.Repo.MainBranch, err)
+	}
+
+	branchCommit, err := r.CommitObject(branchRef.Hash())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mainCommit, err := r.CommitObject(mainRef.Hash())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return branchCommit, mainCommit, nil
+}
+
+// mergeRFDBranch merges the RFD's branch into main in r, fast-forwarding when main hasn't moved.
+// Nothing is pushed. Returns nil if the branch was already merged.
+func mergeRFDBranch(r *git.Repository, worktree *git.Worktree, rfdNum string) (*rfdMergeResult, error) {
+	branchRef := plumbing.NewBranchReferenceName(rfdNum)
+	mainRef := plumbing.NewBranchReferenceName(config.Config.Repo.MainBranch)
+
+	branchCommit, mainCommit, err := branchAndMainCommits(r, branchRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if branchCommit.Hash == mainCommit.Hash {
+		return nil, nil
+	}
+
+	merged, err := branchCommit.IsAncestor(mainCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	if merged {
+		return nil, nil
+	}
+
+	fastForward, err := mainCommit.IsAncestor(branchCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	if fastForward {
+		log.Printf("Fast-forwarding %s to RFD %s (%s)", mainRef.Short(), rfdNum, branchCommit.Hash.String()[:7])
+		if err := r.Storer.SetReference(plumbing.NewHashReference(mainRef, branchCommit.Hash)); err != nil {
+			return nil, fmt.Errorf("failed to fast-forward %s: %w", mainRef.Short(), err)
+		}
+
+		return &rfdMergeResult{Commit: branchCommit.Hash, FastForward: true}, nil
+	}
+
+	bases, err := mainCommit.MergeBase(branchCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("RFD %s branch has no history in common with %s", rfdNum, mainRef.Short())
+	}
+
+	mainChanges, err := changedFiles(bases[0], mainCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	branchChanges, err := changedFiles(bases[0], branchCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []string{}
+	for name, hash := range branchChanges {
+		if mainHash, ok := mainChanges[name]; ok && mainHash != hash {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("%w: RFD %s and %s both changed %s", ErrMergeConflict, rfdNum, mainRef.Short(), strings.Join(conflicts, ", "))
+	}
+
+	log.Printf("Merging RFD %s into %s", rfdNum, mainRef.Short())
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: mainRef, Force: true}); err != nil {
+		return nil, fmt.Errorf("failed to checkout %s: %w", mainRef.Short(), err)
+	}
+
+	for name, hash := range branchChanges {
+		if _, ok := mainChanges[name]; ok {
+			// Changed the same way on both sides
+			continue
+		}
+
+		if hash.IsZero() {
+			if _, err := worktree.Remove(name); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+			continue
+		}
+
+		if err := copyFileFromCommit(worktree, branchCommit, name); err != nil {
+			return nil, err
+		}
+	}
+
+	signature := object.Signature{
+		Name:  config.Config.Repo.CommitAuthorName,
+		Email: config.Config.Repo.CommitAuthorEmail,
+		When:  time.Now(),
+	}
+
+	commitHash, err := worktree.Commit(fmt.Sprintf("Merge RFD %s into %s", rfdNum, mainRef.Short()), &git.CommitOptions{
+		Author:            &signature,
+		Committer:         &signature,
+		Parents:           []plumbing.Hash{mainCommit.Hash, branchCommit.Hash},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return &rfdMergeResult{Commit: commitHash}, nil
+}
+
+// changedFiles returns every file changed between from and to, with its new blob.
+// Deleted files get a zero hash.
+func changedFiles(from *object.Commit, to *object.Commit) (map[string]plumbing.Hash, error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]plumbing.Hash{}
+	for _, change := range changes {
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			files[change.From.Name] = plumbing.ZeroHash
+		}
+
+		if change.To.Name != "" {
+			files[change.To.Name] = change.To.TreeEntry.Hash
+		}
+	}
+
+	return files, nil
+}
+
+// copyFileFromCommit writes name as it is in commit into the worktree and stages it
+func copyFileFromCommit(worktree *git.Worktree, commit *object.Commit, name string) error {
+	file, err := commit.File(name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := worktree.Filesystem.MkdirAll(path.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := worktree.Filesystem.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	_, err = io.Copy(f, reader)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	if _, err := worktree.Add(name); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// recordRFDMerge stores a merge done by mergeRFDBranch
+func recordRFDMerge(rfdNum string, result *rfdMergeResult, actor string) *models.RFDMerge {
+	if result == nil {
+		return nil
+	}
+
+	merge := &models.RFDMerge{
+		RFDID:       rfdNum,
+		Branch:      rfdNum,
+		CommitSHA:   result.Commit.String(),
+		FastForward: result.FastForward,
+		Actor:       actor,
+	}
+
+	if err := _dataStore.CreateRFDMerge(merge); err != nil {
+		log.Printf("Failed to record merge of RFD %s: %v", rfdNum, err)
+	}
+
+	return merge
+}
+
+// MergeRFDBranch merges the RFD's branch into main and pushes it.
+// Does nothing if the RFD has no branch or it's already merged.
+func MergeRFDBranch(id string, actor string) (*models.RFDMerge, error) {
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	var result *rfdMergeResult
+	err := _repoCache.withWorktree(func(r *git.Repository, worktree *git.Worktree) error {
+		checkout, err := checkoutRFD(r, worktree, id, false)
+		if err != nil {
+			return err
+		}
+
+		if !checkout.HasBranch || checkout.Merged {
+			return nil
+		}
+
+		result, err = mergeRFDBranch(r, worktree, id)
+		if err != nil || result == nil {
+			return err
+		}
+
+		log.Printf("Pushing merge of RFD %s", id)
+		if err := _repoCache.push(r); err != nil {
+			return fmt.Errorf("failed to push: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recordRFDMerge(id, result, actor), nil
+}
+
+// mergeRFDBranchAsync merges the RFD's branch in the background.
+// Used when an RFD was published outside of ChangeRFDState, e.g. by a push.
+func mergeRFDBranchAsync(id string, actor string) {
+	go func() {
+		if _, err := MergeRFDBranch(id, actor); err != nil {
+			log.Printf("Failed to merge RFD %s into %s: %v", id, config.Config.Repo.MainBranch, err)
+		}
+	}()
+}
@@ -77,7 +77,16 @@ func OIDCExchangeAuthorizationToken(state string, tokenString string, code strin
 
 	sessionToken.User.LoggedIn = true
 	sessionToken.User.Email = claims.Email
-	sessionToken.User.Name = claims.Email
+	sessionToken.User.Name = claims.Name
+	if sessionToken.User.Name == "" {
+		sessionToken.User.Name = claims.Email
+	}
+	sessionToken.User.Groups = claims.Groups
+
+	// Tokens made while in groups the user has since left stop working now
+	if err := CheckAPITokenGroups(claims.Email, claims.Groups); err != nil {
+		log.Printf("Failed to check API tokens for %s: %v", claims.Email, err)
+	}
 
 	token, expiry, err := EncodeSessionToken(*sessionToken, returnedToken.Expiry)
 	if err != nil {
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/forge"
+)
+
+// openRFDPullRequest opens a PR from the RFD's branch into main and stores its link.
+// Does nothing if forge.openPullRequests is off or the RFD already has a PR.
+func openRFDPullRequest(rfdID string) error {
+	if _forge == nil || !config.Config.Forge.OpenPullRequests {
+		return nil
+	}
+
+	rfd, err := _dataStore.GetRFDByID(rfdID)
+	if err != nil {
+		return err
+	}
+
+	if rfd == nil {
+		return fmt.Errorf("rfd %s not found", rfdID)
+	}
+
+	if rfd.PRLink != "" {
+		return nil
+	}
+
+	result, err := _forge.OpenPullRequest(forge.PullRequest{
+		Title: fmt.Sprintf("RFD %s: %s", rfd.ID, rfd.Title),
+		Body:  fmt.Sprintf("%s/%s", config.Config.Site.URL, rfd.ID),
+		Head:  rfd.ID,
+		Base:  config.Config.Repo.MainBranch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open pull request: %w", err)
+	}
+
+	log.Printf("Opened pull request for RFD %s: %s", rfd.ID, result.URL)
+
+	// The RFD may have been updated while the PR was being opened
+	lock := getRFDLock(rfd.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	rfd, err = _dataStore.GetRFDByID(rfd.ID)
+	if err != nil {
+		return err
+	}
+
+	rfd.PRLink = result.URL
+
+	return _dataStore.UpdateRFD(rfd)
+}
+
+// openRFDPullRequestAsync opens the RFD's PR in the background, logging any failure
+func openRFDPullRequestAsync(rfdID string) {
+	if _forge == nil || !config.Config.Forge.OpenPullRequests {
+		return
+	}
+
+	go func() {
+		if err := openRFDPullRequest(rfdID); err != nil {
+			log.Printf("Failed to open pull request for RFD %s: %v", rfdID, err)
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// GetRFDRelations returns the RFDs an RFD is related to, including inverse links from
+// RFDs that point at it. Links to RFDs that don't exist or viewer can't see are left out,
+// the ones the RFD declares itself are listed as missing instead.
+func GetRFDRelations(id string, viewer models.Viewer) (*models.RFDRelations, error) {
+	if id == "" {
+		return nil, errors.New("no id provided")
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	stored, err := _dataStore.GetRFDRelations(id)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := &models.RFDRelations{
+		Supersedes:   []models.RFDRelationLink{},
+		SupersededBy: []models.RFDRelationLink{},
+		DependsOn:    []models.RFDRelationLink{},
+		RequiredBy:   []models.RFDRelationLink{},
+		Related:      []models.RFDRelationLink{},
+		Missing:      []models.RFDRelation{},
+	}
+
+	rfds := map[string]*models.RFD{}
+	related := map[string]bool{}
+
+	for _, relation := range stored {
+		outgoing := relation.RFDID == id
+
+		otherID := relation.TargetID
+		if !outgoing {
+			otherID = relation.RFDID
+		}
+
+		var list *[]models.RFDRelationLink
+		switch {
+		case relation.Kind == models.Related:
+			// Both RFDs may list each other, only show it once
+			if related[otherID] {
+				continue
+			}
+			related[otherID] = true
+			list = &relations.Related
+		case relation.Kind == models.Supersedes && outgoing:
+			list = &relations.Supersedes
+		case relation.Kind == models.Supersedes:
+			list = &relations.SupersededBy
+		case relation.Kind == models.DependsOn && outgoing:
+			list = &relations.DependsOn
+		case relation.Kind == models.DependsOn:
+			list = &relations.RequiredBy
+		default:
+			continue
+		}
+
+		other, ok := rfds[otherID]
+		if !ok {
+			other, err = _dataStore.GetRFDByID(otherID)
+			if err != nil {
+				return nil, err
+			}
+			rfds[otherID] = other
+		}
+
+		if other == nil || !other.VisibleTo(viewer) {
+			// Restricted RFDs count as missing too, so it doesn't give away that they exist
+			if outgoing {
+				relations.Missing = append(relations.Missing, relation)
+			}
+			continue
+		}
+
+		*list = append(*list, models.RFDRelationLink{ID: other.ID, Title: other.Title, State: other.State})
+	}
+
+	return relations, nil
+}
+
+// validRFDRelations splits the relations declared by rfd into ones that can be
+// stored and ones that don't point at another RFD
+func validRFDRelations(rfd *models.RFD) ([]models.RFDRelation, []models.RFDRelation) {
+	valid := []models.RFDRelation{}
+	invalid := []models.RFDRelation{}
+	for _, relation := range rfd.Relations {
+		relation.RFDID = rfd.ID
+
+		if !relation.Kind.Valid() || !_rfdBranch.MatchString(relation.TargetID) || relation.TargetID == rfd.ID {
+			invalid = append(invalid, relation)
+			continue
+		}
+
+		valid = append(valid, relation)
+	}
+
+	return valid, invalid
+}
+
+// syncRFDRelations stores the relations declared in rfd's frontmatter if they changed.
+// Links to RFDs that don't exist yet are kept but flagged as missing on the RFD's page,
+// they show up as links once the RFD does.
+func syncRFDRelations(rfd *models.RFD) (bool, error) {
+	relations, invalid := validRFDRelations(rfd)
+
+	stored, err := _dataStore.GetRFDRelations(rfd.ID)
+	if err != nil {
+		return false, err
+	}
+
+	declared := []models.RFDRelation{}
+	for _, relation := range stored {
+		if relation.RFDID == rfd.ID {
+			declared = append(declared, relation)
+		}
+	}
+
+	if reflect.DeepEqual(relations, declared) {
+		return false, nil
+	}
+
+	for _, relation := range invalid {
+		log.Printf("Ignoring %s %q on RFD %s, it isn't another RFD's number", relation.Kind, relation.TargetID, rfd.ID)
+	}
+
+	for _, relation := range relations {
+		target, err := _dataStore.GetRFDByID(relation.TargetID)
+		if err != nil {
+			return false, err
+		}
+
+		if target == nil {
+			log.Printf("RFD %s %s RFD %s, which doesn't exist, flagging it as missing", rfd.ID, relation.Kind, relation.TargetID)
+		}
+	}
+
+	if err := _dataStore.SetRFDRelations(rfd.ID, relations); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
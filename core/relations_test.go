@@ -0,0 +1,82 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestValidRFDRelations(t *testing.T) {
+	rfd := &models.RFD{ID: "0005", Relations: []models.RFDRelation{
+		{Kind: models.Supersedes, TargetID: "0001"},
+		{Kind: models.DependsOn, TargetID: "0005"},
+		{Kind: models.Related, TargetID: "abc"},
+		{Kind: "blocks", TargetID: "0002"},
+		{Kind: models.Related, TargetID: "0009"},
+	}}
+
+	valid, invalid := validRFDRelations(rfd)
+
+	want := []models.RFDRelation{
+		{RFDID: "0005", Kind: models.Supersedes, TargetID: "0001"},
+		{RFDID: "0005", Kind: models.Related, TargetID: "0009"},
+	}
+
+	if !reflect.DeepEqual(valid, want) {
+		t.Errorf("Expected %+v, got %+v", want, valid)
+	}
+
+	if len(invalid) != 3 {
+		t.Errorf("Expected the self link, non-number and unknown kind to be invalid, got %+v", invalid)
+	}
+}
+
+func TestRelationToMissingRFD(t *testing.T) {
+	newTestDataStore(t)
+
+	target := &models.RFD{ID: "0002", RFDMeta: models.RFDMeta{Title: "Storage"}}
+	if err := _dataStore.ImportRFD(target); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	rfd := &models.RFD{ID: "0001", RFDMeta: models.RFDMeta{Title: "Backups"}, Relations: []models.RFDRelation{
+		{Kind: models.DependsOn, TargetID: "0002"},
+		{Kind: models.Supersedes, TargetID: "0009"},
+	}}
+	if err := _dataStore.ImportRFD(rfd); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	if _, err := syncRFDRelations(rfd); err != nil {
+		t.Fatalf("Failed to sync relations: %v", err)
+	}
+
+	relations, err := GetRFDRelations("0001", models.Viewer{LoggedIn: true})
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+
+	if len(relations.DependsOn) != 1 || relations.DependsOn[0].ID != "0002" {
+		t.Errorf("Expected a link to 0002, got %+v", relations.DependsOn)
+	}
+
+	if len(relations.Supersedes) != 0 {
+		t.Errorf("Expected no link to the missing RFD, got %+v", relations.Supersedes)
+	}
+
+	want := []models.RFDRelation{{RFDID: "0001", Kind: models.Supersedes, TargetID: "0009"}}
+	if !reflect.DeepEqual(relations.Missing, want) {
+		t.Errorf("Expected %+v to be flagged as missing, got %+v", want, relations.Missing)
+	}
+
+	// RFD 0002 doesn't declare anything, so has nothing missing
+	relations, err = GetRFDRelations("0002", models.Viewer{LoggedIn: true})
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+
+	if len(relations.RequiredBy) != 1 || len(relations.Missing) != 0 {
+		t.Errorf("Expected 0002 to be required by 0001 with nothing missing, got %+v", relations)
+	}
+}
@@ -0,0 +1,336 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// repoCache is a bare clone of the RFD repo kept on disk under dataPath.
+// It's fetched before every use instead of cloning the whole repo again, and
+// only one caller uses it at a time so writers don't race each other on push.
+type repoCache struct {
+	path       string
+	url        string
+	auth       transport.AuthMethod
+	mainBranch string
+
+	mu   sync.Mutex
+	repo *git.Repository
+}
+
+// repoCacheName is the folder under dataPath the repo is cloned into
+const repoCacheName = "repo.git"
+
+var _repoCache *repoCache
+
+func newRepoCache(path string, url string, auth transport.AuthMethod, mainBranch string) *repoCache {
+	return &repoCache{
+		path:       path,
+		url:        url,
+		auth:       auth,
+		mainBranch: mainBranch,
+	}
+}
+
+// withRepo runs fn against the freshly fetched cache. Local branches match the
+// remote's main branch only, RFD branches are under refs/remotes/origin.
+// Anything fn pushes should be pushed from local branches.
+func (c *repoCache) withRepo(fn func(r *git.Repository) error) error {
+	return c.withBranches(nil, fn)
+}
+
+// withBranches is withRepo fetching only the main branch and branches, for when we know
+// what changed. Every other branch is left as it was last fetched. nil fetches everything.
+func (c *repoCache) withBranches(branches []string, fn func(r *git.Repository) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	if err := c.fetch(r, branches); err != nil {
+		return err
+	}
+
+	if err := c.resetBranches(r); err != nil {
+		return err
+	}
+
+	return fn(r)
+}
+
+// withWorktree is withRepo with an in-memory worktree checked out on the main branch
+func (c *repoCache) withWorktree(fn func(r *git.Repository, worktree *git.Worktree) error) error {
+	return c.withRepo(func(bare *git.Repository) error {
+		// The index belongs to whatever used the cache last, start from an empty one
+		if err := bare.Storer.SetIndex(&index.Index{Version: 2}); err != nil {
+			return err
+		}
+
+		r, err := git.Open(bare.Storer, memfs.New())
+		if err != nil {
+			return err
+		}
+
+		worktree, err := r.Worktree()
+		if err != nil {
+			return err
+		}
+
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(c.mainBranch), Force: true}); err != nil {
+			return fmt.Errorf("failed to checkout %s: %w", c.mainBranch, err)
+		}
+
+		return fn(r, worktree)
+	})
+}
+
+// checkAccess makes sure the repo can be reached with the configured credentials
+// and has the main branch, without touching the cache
+func (c *repoCache) checkAccess() error {
+	hasMain, err := c.remoteHasBranch(c.mainBranch)
+	if err != nil {
+		return fmt.Errorf("failed to reach repo %s: %w", c.url, err)
+	}
+
+	if !hasMain {
+		return fmt.Errorf("repo %s has no %s branch", c.url, c.mainBranch)
+	}
+
+	return nil
+}
+
+// remoteHasBranch asks the remote whether it has branch right now, without touching the cache
+func (c *repoCache) remoteHasBranch(branch string) (bool, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{Name: "origin", URLs: []string{c.url}})
+
+	refs, err := remote.List(&git.ListOptions{Auth: c.auth})
+	if err != nil {
+		return false, err
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == branchRef {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// open opens the cache, cloning the repo the first time
+func (c *repoCache) open() (*git.Repository, error) {
+	if c.repo != nil {
+		return c.repo, nil
+	}
+
+	r, err := git.PlainOpen(c.path)
+	if err == git.ErrRepositoryNotExists {
+		log.Printf("Cloning RFD Repo into %s", c.path)
+		r, err = git.PlainClone(c.path, true, &git.CloneOptions{
+			URL:      c.url,
+			Auth:     c.auth,
+			Tags:     git.NoTags,
+			Progress: os.Stdout,
+		})
+		if err != nil {
+			// Don't leave a half cloned repo behind for the next attempt to trip over
+			os.RemoveAll(c.path)
+			return nil, fmt.Errorf("failed to clone repo: %w", err)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo cache %s: %w", c.path, err)
+	}
+
+	c.repo = r
+
+	return r, nil
+}
+
+// fetch brings branches up to date with the remote, dropping ones deleted there.
+// The main branch is always fetched, nil fetches every branch.
+func (c *repoCache) fetch(r *git.Repository, only []string) error {
+	remote, err := r.Remote("origin")
+	if err != nil {
+		return err
+	}
+
+	remoteRefs, err := remote.List(&git.ListOptions{Auth: c.auth})
+	if err != nil {
+		return fmt.Errorf("failed to list remote branches: %w", err)
+	}
+
+	branches := map[plumbing.ReferenceName]bool{}
+	for _, ref := range remoteRefs {
+		if ref.Name().IsBranch() {
+			branches[plumbing.NewRemoteReferenceName("origin", ref.Name().Short())] = true
+		}
+	}
+
+	refSpecs := []gitconfig.RefSpec{"+refs/heads/*:refs/remotes/origin/*"}
+
+	// Asking for a branch the remote doesn't have fails the whole fetch, so only ask for ones it has
+	var wanted map[plumbing.ReferenceName]bool
+	if only != nil {
+		wanted = map[plumbing.ReferenceName]bool{}
+		refSpecs = []gitconfig.RefSpec{}
+		for _, branch := range append([]string{c.mainBranch}, only...) {
+			name := plumbing.NewRemoteReferenceName("origin", branch)
+			if wanted[name] {
+				continue
+			}
+
+			wanted[name] = true
+			if branches[name] {
+				refSpecs = append(refSpecs, gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)))
+			}
+		}
+	}
+
+	err = r.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   refSpecs,
+		Auth:       c.auth,
+		Tags:       git.NoTags,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("failed to fetch repo: %w", err)
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return err
+	}
+
+	stale := []plumbing.ReferenceName{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference && !branches[ref.Name()] && (wanted == nil || wanted[ref.Name()]) {
+			stale = append(stale, ref.Name())
+		}
+		return nil
+	})
+	refs.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stale {
+		log.Printf("Branch %s was deleted from the remote, dropping it", name.Short())
+		if err := r.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetBranches throws away local branches and points main back at the remote,
+// so nothing left over from an earlier change that failed to push leaks into the next one
+func (c *repoCache) resetBranches(r *git.Repository) error {
+	refs, err := r.References()
+	if err != nil {
+		return err
+	}
+
+	local := []plumbing.ReferenceName{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() {
+			local = append(local, ref.Name())
+		}
+		return nil
+	})
+	refs.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range local {
+		if err := r.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+
+	mainRef := plumbing.NewBranchReferenceName(c.mainBranch)
+
+	if err := trackRemoteBranch(r, c.mainBranch); err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", c.mainBranch, err)
+	}
+
+	return r.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, mainRef))
+}
+
+// push pushes every local branch to the remote
+func (c *repoCache) push(r *git.Repository) error {
+	return r.Push(&git.PushOptions{RemoteName: "origin", Auth: c.auth})
+}
+
+// pushBranch pushes only the local branch, refusing to overwrite it if it already exists on the remote
+func (c *repoCache) pushBranch(r *git.Repository, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+
+	return r.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("%s:%s", ref, ref))},
+		Auth:       c.auth,
+	})
+}
+
+// errBlobTooLarge is returned by readBlob for blobs over the size limit
+var errBlobTooLarge = errors.New("blob too large")
+
+// readBlob reads a blob found by an earlier withRepo without fetching again.
+// Objects never change, so the hash is enough to find the right content.
+func (c *repoCache) readBlob(hash plumbing.Hash, maxSize int64) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := r.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if blob.Size > maxSize {
+		return nil, errBlobTooLarge
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// trackRemoteBranch points the local branch at the remote's copy of it.
+// Returns plumbing.ErrReferenceNotFound if the remote doesn't have the branch.
+func trackRemoteBranch(r *git.Repository, branch string) error {
+	remoteRef, err := r.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return err
+	}
+
+	return r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), remoteRef.Hash()))
+}
@@ -0,0 +1,461 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// newTestRemote creates a bare repo with a commit on main and one on branch 0001
+func newTestRemote(t *testing.T) (string, *git.Repository) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "remote.git")
+	remote, err := git.PlainInit(path, true)
+	if err != nil {
+		t.Fatalf("Failed to create remote: %v", err)
+	}
+
+	if err := remote.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))); err != nil {
+		t.Fatalf("Failed to set remote HEAD: %v", err)
+	}
+
+	seed, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("Failed to create seed repo: %v", err)
+	}
+
+	if err := seed.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))); err != nil {
+		t.Fatalf("Failed to set seed HEAD: %v", err)
+	}
+
+	worktree, _ := seed.Worktree()
+	commitTestFile(t, worktree, "README.md", "main")
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("0001"), Create: true}); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+	commitTestFile(t, worktree, "rfds/0001/README.md", "rfd")
+
+	if _, err := seed.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{path}}); err != nil {
+		t.Fatalf("Failed to add remote: %v", err)
+	}
+
+	if err := seed.Push(&git.PushOptions{RemoteName: "origin"}); err != nil {
+		t.Fatalf("Failed to push seed repo: %v", err)
+	}
+
+	return path, remote
+}
+
+// useTestRemote points core's repo cache at a new test remote, use after newTestDataStore
+func useTestRemote(t *testing.T) (string, *git.Repository) {
+	t.Helper()
+
+	remotePath, remote := newTestRemote(t)
+
+	config.Config.Repo.Folder = "rfds"
+	config.Config.Repo.MainBranch = "main"
+	config.Config.Repo.CommitAuthorName = "RFD Tool"
+	config.Config.Repo.CommitAuthorEmail = "rfd-tool@example.com"
+
+	_repoCache = newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "main")
+
+	return remotePath, remote
+}
+
+// pushTestFile commits name to branch on the remote, as if someone else pushed it
+func pushTestFile(t *testing.T, remotePath string, branch string, name string, content string) plumbing.Hash {
+	t.Helper()
+
+	clone, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:           remotePath,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to clone %s: %v", branch, err)
+	}
+
+	worktree, _ := clone.Worktree()
+	hash := commitTestFile(t, worktree, name, content)
+
+	if err := clone.Push(&git.PushOptions{RemoteName: "origin"}); err != nil {
+		t.Fatalf("Failed to push %s: %v", branch, err)
+	}
+
+	return hash
+}
+
+// testRFDFile is an RFD's README.md with frontmatter
+func testRFDFile(title string, state models.RFDState) string {
+	return fmt.Sprintf("---\ntitle: %s\nauthors:\n  - Jane Doe <jane@example.com>\nstate: %s\n---\n\nBody.\n", title, state)
+}
+
+// remoteFile reads name as it is on branch in the remote
+func remoteFile(t *testing.T, r *git.Repository, branch string, name string) string {
+	t.Helper()
+
+	commit, err := r.CommitObject(branchHash(t, r, plumbing.NewBranchReferenceName(branch)))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", branch, err)
+	}
+
+	file, err := commit.File(name)
+	if err != nil {
+		t.Fatalf("Failed to read %s on %s: %v", name, branch, err)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		t.Fatalf("Failed to read %s on %s: %v", name, branch, err)
+	}
+
+	return content
+}
+
+func commitTestFile(t *testing.T, worktree *git.Worktree, name string, content string) plumbing.Hash {
+	t.Helper()
+
+	f, err := worktree.Filesystem.Create(name)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", name, err)
+	}
+	f.Write([]byte(content))
+	f.Close()
+
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatalf("Failed to add %s: %v", name, err)
+	}
+
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	hash, err := worktree.Commit("Update "+name, &git.CommitOptions{Author: signature})
+	if err != nil {
+		t.Fatalf("Failed to commit %s: %v", name, err)
+	}
+
+	return hash
+}
+
+func branchHash(t *testing.T, r *git.Repository, name plumbing.ReferenceName) plumbing.Hash {
+	t.Helper()
+
+	ref, err := r.Reference(name, true)
+	if err != nil {
+		t.Fatalf("Failed to resolve %s: %v", name, err)
+	}
+
+	return ref.Hash()
+}
+
+func TestRepoCacheCommitAndPush(t *testing.T) {
+	remotePath, remote := newTestRemote(t)
+	cache := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "main")
+
+	var pushed plumbing.Hash
+	err := cache.withWorktree(func(r *git.Repository, worktree *git.Worktree) error {
+		if _, err := worktree.Filesystem.Stat("README.md"); err != nil {
+			t.Errorf("Expected main to be checked out: %v", err)
+		}
+
+		pushed = commitTestFile(t, worktree, "README.md", "changed")
+
+		return r.Push(&git.PushOptions{RemoteName: "origin"})
+	})
+	if err != nil {
+		t.Fatalf("Failed to use cache: %v", err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != pushed {
+		t.Errorf("Expected remote main at %s, got %s", pushed, got)
+	}
+}
+
+func TestRepoCacheDiscardsUnpushedCommits(t *testing.T) {
+	remotePath, remote := newTestRemote(t)
+	cache := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "main")
+
+	failed := errors.New("push failed")
+	err := cache.withWorktree(func(r *git.Repository, worktree *git.Worktree) error {
+		commitTestFile(t, worktree, "README.md", "never pushed")
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("Expected the callback's error, got %v", err)
+	}
+
+	// A new cache on the same path picks up the existing clone
+	cache = newRepoCache(cache.path, remotePath, nil, "main")
+
+	err = cache.withRepo(func(r *git.Repository) error {
+		mainRef := plumbing.NewBranchReferenceName("main")
+		if got, want := branchHash(t, r, mainRef), branchHash(t, remote, mainRef); got != want {
+			t.Errorf("Expected main reset to %s, got %s", want, got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to use cache: %v", err)
+	}
+}
+
+func TestRepoCacheDropsDeletedBranches(t *testing.T) {
+	remotePath, remote := newTestRemote(t)
+	cache := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "main")
+
+	branch := plumbing.NewRemoteReferenceName("origin", "0001")
+
+	err := cache.withRepo(func(r *git.Repository) error {
+		if _, err := r.Reference(branch, true); err != nil {
+			t.Errorf("Expected %s to be fetched: %v", branch, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to use cache: %v", err)
+	}
+
+	if err := remote.Storer.RemoveReference(plumbing.NewBranchReferenceName("0001")); err != nil {
+		t.Fatalf("Failed to delete branch: %v", err)
+	}
+
+	err = cache.withRepo(func(r *git.Repository) error {
+		if _, err := r.Reference(branch, true); err != plumbing.ErrReferenceNotFound {
+			t.Errorf("Expected %s to be dropped, got %v", branch, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to use cache: %v", err)
+	}
+}
+
+func TestRepoCacheCheckAccess(t *testing.T) {
+	remotePath, _ := newTestRemote(t)
+
+	if err := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "main").checkAccess(); err != nil {
+		t.Errorf("Expected access to the remote, got %v", err)
+	}
+
+	if err := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), remotePath, nil, "trunk").checkAccess(); err == nil {
+		t.Error("Expected an error for a missing main branch")
+	}
+
+	if err := newRepoCache(filepath.Join(t.TempDir(), repoCacheName), filepath.Join(t.TempDir(), "missing.git"), nil, "main").checkAccess(); err == nil {
+		t.Error("Expected an error for a missing repo")
+	}
+}
+
+func TestMergeRFDBranchFastForward(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	_, remote := useTestRemote(t)
+
+	branchHead := branchHash(t, remote, plumbing.NewBranchReferenceName("0001"))
+
+	merge, err := MergeRFDBranch("1", "jane@example.com")
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	if merge == nil || !merge.FastForward || merge.CommitSHA != branchHead.String() {
+		t.Errorf("Expected a fast-forward to %s, got %+v", branchHead, merge)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != branchHead {
+		t.Errorf("Expected remote main fast-forwarded to %s, got %s", branchHead, got)
+	}
+}
+
+func TestMergeRFDBranchMergesChangesToOtherFiles(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	mainHead := pushTestFile(t, remotePath, "main", "rfds/0002/README.md", "another rfd")
+	branchHead := branchHash(t, remote, plumbing.NewBranchReferenceName("0001"))
+
+	merge, err := MergeRFDBranch("0001", "jane@example.com")
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	if merge == nil || merge.FastForward {
+		t.Fatalf("Expected a merge commit, got %+v", merge)
+	}
+
+	mergeHash := branchHash(t, remote, plumbing.NewBranchReferenceName("main"))
+	if merge.CommitSHA != mergeHash.String() {
+		t.Errorf("Expected remote main at the merge %s, got %s", merge.CommitSHA, mergeHash)
+	}
+
+	commit, err := remote.CommitObject(mergeHash)
+	if err != nil {
+		t.Fatalf("Failed to read merge commit: %v", err)
+	}
+
+	if len(commit.ParentHashes) != 2 || commit.ParentHashes[0] != mainHead || commit.ParentHashes[1] != branchHead {
+		t.Errorf("Expected parents %s and %s, got %v", mainHead, branchHead, commit.ParentHashes)
+	}
+
+	for name, want := range map[string]string{
+		"README.md":           "main",
+		"rfds/0001/README.md": "rfd",
+		"rfds/0002/README.md": "another rfd",
+	} {
+		if got := remoteFile(t, remote, "main", name); got != want {
+			t.Errorf("Expected %s to be %q after the merge, got %q", name, want, got)
+		}
+	}
+
+	merges, err := GetRFDMerges("1")
+	if err != nil {
+		t.Fatalf("Failed to get merges: %v", err)
+	}
+
+	if len(merges) != 1 || merges[0].CommitSHA != mergeHash.String() || merges[0].Actor != "jane@example.com" {
+		t.Errorf("Expected the merge to be recorded, got %+v", merges)
+	}
+}
+
+func TestMergeRFDBranchConflict(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	mainHead := pushTestFile(t, remotePath, "main", "rfds/0001/README.md", "changed on main")
+
+	merge, err := MergeRFDBranch("0001", "jane@example.com")
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("Expected a merge conflict, got %+v, %v", merge, err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != mainHead {
+		t.Errorf("Expected remote main left at %s, got %s", mainHead, got)
+	}
+
+	merges, err := GetRFDMerges("0001")
+	if err != nil {
+		t.Fatalf("Failed to get merges: %v", err)
+	}
+
+	if len(merges) != 0 {
+		t.Errorf("Expected no merges to be recorded, got %+v", merges)
+	}
+}
+
+func TestMergeRFDBranchAlreadyMerged(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	_, remote := useTestRemote(t)
+
+	if _, err := MergeRFDBranch("0001", "jane@example.com"); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	mainHead := branchHash(t, remote, plumbing.NewBranchReferenceName("main"))
+
+	merge, err := MergeRFDBranch("0001", "jane@example.com")
+	if err != nil || merge != nil {
+		t.Errorf("Expected nothing to merge, got %+v, %v", merge, err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != mainHead {
+		t.Errorf("Expected remote main left at %s, got %s", mainHead, got)
+	}
+
+	merges, err := GetRFDMerges("0001")
+	if err != nil {
+		t.Fatalf("Failed to get merges: %v", err)
+	}
+
+	if len(merges) != 1 {
+		t.Errorf("Expected only the first merge to be recorded, got %+v", merges)
+	}
+}
+
+func TestCommitRFDChangeMergesIntoMain(t *testing.T) {
+	newTestDataStore(t)
+	remotePath, remote := useTestRemote(t)
+
+	pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+	mainHead := pushTestFile(t, remotePath, "main", "rfds/0002/README.md", "another rfd")
+
+	commit, err := commitRFDChange("0001", rfdChange{
+		Message:       "Publish RFD 0001",
+		MergeIntoMain: true,
+		Update: func(rfdMeta *models.RFDMetaYAML, body []byte) []byte {
+			rfdMeta.State = models.Published
+			return body
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	if commit.Merge == nil || commit.Merge.FastForward {
+		t.Fatalf("Expected a merge commit, got %+v", commit.Merge)
+	}
+
+	// The change went on the branch, which was then merged
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("0001")); got != commit.Commit {
+		t.Errorf("Expected remote 0001 at %s, got %s", commit.Commit, got)
+	}
+
+	mergeHash := branchHash(t, remote, plumbing.NewBranchReferenceName("main"))
+	if mergeHash != commit.Merge.Commit {
+		t.Errorf("Expected remote main at the merge %s, got %s", commit.Merge.Commit, mergeHash)
+	}
+
+	mergeCommit, err := remote.CommitObject(mergeHash)
+	if err != nil {
+		t.Fatalf("Failed to read merge commit: %v", err)
+	}
+
+	if len(mergeCommit.ParentHashes) != 2 || mergeCommit.ParentHashes[0] != mainHead || mergeCommit.ParentHashes[1] != commit.Commit {
+		t.Errorf("Expected parents %s and %s, got %v", mainHead, commit.Commit, mergeCommit.ParentHashes)
+	}
+
+	if got := remoteFile(t, remote, "main", "rfds/0001/README.md"); got != string(commit.File) {
+		t.Errorf("Expected the published RFD on main, got %q", got)
+	}
+}
+
+func TestCommitRFDChangeMergeConflictPushesNothing(t *testing.T) {
+	newTestDataStore(t)
+	remotePath, remote := useTestRemote(t)
+
+	branchHead := pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+	mainHead := pushTestFile(t, remotePath, "main", "rfds/0001/README.md", testRFDFile("Replication on main", models.Discussion))
+
+	_, err := commitRFDChange("0001", rfdChange{
+		Message:       "Publish RFD 0001",
+		MergeIntoMain: true,
+		Update: func(rfdMeta *models.RFDMetaYAML, body []byte) []byte {
+			rfdMeta.State = models.Published
+			return body
+		},
+	})
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("Expected a merge conflict, got %v", err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("main")); got != mainHead {
+		t.Errorf("Expected remote main left at %s, got %s", mainHead, got)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("0001")); got != branchHead {
+		t.Errorf("Expected remote 0001 left at %s, got %s", branchHead, got)
+	}
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const (
+	// repoWriteBatch is how many queued writes are claimed at a time
+	repoWriteBatch = 10
+
+	// repoWriteLease is how long a claimed write is left alone before it's
+	// considered abandoned, e.g. by a server that died while pushing it
+	repoWriteLease = 2 * time.Minute
+
+	// repoWriteMaxAttempts is how many times a write is tried before it's marked failed
+	repoWriteMaxAttempts = 10
+
+	// repoWritePollInterval is how often the worker looks for writes due a retry
+	repoWritePollInterval = 30 * time.Second
+)
+
+// Wakes the worker when a write is queued, instead of waiting for the next poll
+var _repoWriteWake = make(chan struct{}, 1)
+
+// StartRepoWriteWorker starts the background loop that commits queued repo writes.
+// Anything left in the queue from before a restart is picked up straight away.
+func StartRepoWriteWorker() {
+	log.Println("Starting repo write worker")
+
+	go func() {
+		for {
+			processRepoWrites()
+
+			select {
+			case <-_repoWriteWake:
+			case <-time.After(repoWritePollInterval):
+			}
+		}
+	}()
+}
+
+func wakeRepoWriteWorker() {
+	select {
+	case _repoWriteWake <- struct{}{}:
+	default:
+	}
+}
+
+// queueDiscussionLinkCommit queues committing an RFD's discussion link to its README.md
+func queueDiscussionLinkCommit(rfdID string, discussionURL string) {
+	write := &models.RepoWrite{
+		Kind:  models.RepoWriteDiscussionLink,
+		RFDID: rfdID,
+		Value: discussionURL,
+	}
+
+	if err := _dataStore.CreateRepoWrite(write); err != nil {
+		log.Printf("Failed to queue discussion link commit for RFD %s: %v", rfdID, err)
+		return
+	}
+
+	wakeRepoWriteWorker()
+}
+
+// processRepoWrites runs every write that's due until there are none left
+func processRepoWrites() {
+	for {
+		now := time.Now()
+
+		writes, err := _dataStore.ClaimRepoWrites(now, now.Add(repoWriteLease), repoWriteBatch)
+		if err != nil {
+			log.Printf("Failed to claim repo writes: %v", err)
+			return
+		}
+
+		if len(writes) == 0 {
+			return
+		}
+
+		for i := range writes {
+			runRepoWrite(&writes[i])
+		}
+	}
+}
+
+// runRepoWrite applies a claimed write, scheduling a retry with backoff if it fails.
+// Writes superseded by a newer one after they were claimed are dropped.
+func runRepoWrite(write *models.RepoWrite) {
+	current, err := _dataStore.GetRepoWrite(write.ID)
+	if err != nil {
+		log.Printf("Failed to get repo write %d: %v", write.ID, err)
+		return
+	}
+
+	if current == nil || current.Status == models.RepoWriteDone {
+		log.Printf("Repo write %d (%s for RFD %s) was superseded, dropping it", write.ID, write.Kind, write.RFDID)
+		return
+	}
+
+	err = applyRepoWrite(write)
+	if err == nil {
+		log.Printf("Repo write %d (%s for RFD %s) done", write.ID, write.Kind, write.RFDID)
+		write.Status = models.RepoWriteDone
+		write.LastError = ""
+	} else {
+		write.LastError = err.Error()
+
+		if write.Attempts >= repoWriteMaxAttempts {
+			log.Printf("Giving up on repo write %d (%s for RFD %s) after %d attempts: %v", write.ID, write.Kind, write.RFDID, write.Attempts, err)
+			write.Status = models.RepoWriteFailed
+		} else {
+			write.NextAttemptAt = time.Now().Add(repoWriteBackoff(write.Attempts))
+			log.Printf("Repo write %d (%s for RFD %s) failed, retrying at %s: %v", write.ID, write.Kind, write.RFDID, write.NextAttemptAt.Format(time.RFC3339), err)
+		}
+	}
+
+	if err := _dataStore.UpdateRepoWrite(write); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Superseded while it ran, the newer write lands after it
+			log.Printf("Repo write %d (%s for RFD %s) was superseded while running, dropping it", write.ID, write.Kind, write.RFDID)
+			return
+		}
+
+		log.Printf("Failed to update repo write %d: %v", write.ID, err)
+	}
+}
+
+func applyRepoWrite(write *models.RepoWrite) error {
+	switch write.Kind {
+	case models.RepoWriteDiscussionLink:
+		return UpdateRFDDiscussionInRepo(write.RFDID, write.Value)
+	default:
+		return fmt.Errorf("unknown repo write kind: %s", write.Kind)
+	}
+}
+
+// repoWriteBackoff is how long to wait before the next attempt, doubling from 30s up to an hour
+func repoWriteBackoff(attempts int) time.Duration {
+	backoff := 30 * time.Second
+	for i := 1; i < attempts && backoff < time.Hour; i++ {
+		backoff *= 2
+	}
+
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+
+	return backoff
+}
+
+// GetRepoWrites returns the most recent queued repo writes with status
+func GetRepoWrites(status models.RepoWriteStatus) ([]models.RepoWrite, error) {
+	return _dataStore.GetRepoWrites(status, 100)
+}
+
+// RetryRepoWrite puts a failed write back in the queue with a fresh set of attempts.
+// Returns nil if there's no such write.
+func RetryRepoWrite(id int64) (*models.RepoWrite, error) {
+	write, err := _dataStore.GetRepoWrite(id)
+	if err != nil || write == nil {
+		return nil, err
+	}
+
+	if write.Status != models.RepoWriteFailed {
+		return write, nil
+	}
+
+	write.Status = models.RepoWritePending
+	write.Attempts = 0
+	write.NextAttemptAt = time.Now()
+
+	if err := _dataStore.UpdateRepoWrite(write); err != nil {
+		return nil, err
+	}
+
+	wakeRepoWriteWorker()
+
+	return write, nil
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/diff"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/webhook"
+)
+
+// RevisionDiff is what changed in an RFD between two revisions
+type RevisionDiff struct {
+	From *models.RFDRevision // nil when To is the first revision
+	To   *models.RFDRevision
+
+	Changes *webhook.RFDChanges // nil when the metadata is the same
+	Content []diff.Op
+}
+
+// GetRFDRevisions returns the revision history of an RFD, newest first
+func GetRFDRevisions(id string) ([]models.RFDRevision, error) {
+	if id == "" {
+		return nil, errors.New("no id provided")
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	return _dataStore.GetRevisions(id)
+}
+
+// GetRFDRevisionDiff diffs two revisions of an RFD.
+// A zero to means the latest revision, a zero from means the one before to.
+func GetRFDRevisionDiff(id string, from int64, to int64) (*RevisionDiff, error) {
+	revisions, err := GetRFDRevisions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, nil
+	}
+
+	// Revisions are newest first
+	toIndex := 0
+	if to != 0 {
+		toIndex = revisionIndex(revisions, to)
+		if toIndex < 0 {
+			return nil, nil
+		}
+	}
+
+	fromIndex := toIndex + 1
+	if from != 0 {
+		fromIndex = revisionIndex(revisions, from)
+		if fromIndex < 0 {
+			return nil, nil
+		}
+	}
+
+	result := &RevisionDiff{To: &revisions[toIndex]}
+
+	previous := &models.RFD{}
+	if fromIndex < len(revisions) {
+		result.From = &revisions[fromIndex]
+		previous = result.From.AsRFD()
+	}
+
+	current := result.To.AsRFD()
+
+	result.Changes = webhook.DetectChanges(previous, current)
+	result.Content = diff.Words(previous.ContentMD, current.ContentMD)
+
+	return result, nil
+}
+
+func revisionIndex(revisions []models.RFDRevision, id int64) int {
+	for i, revision := range revisions {
+		if revision.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// recordRevision snapshots the stored copy of an RFD if it differs from previous.
+// previous is nil for a brand new RFD, which always gets a first revision.
+func recordRevision(previous *models.RFD, rfdID string, source *models.ChangeSource) error {
+	current, err := _dataStore.GetRFDByID(rfdID)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		return fmt.Errorf("rfd %s not found", rfdID)
+	}
+
+	if previous != nil && !storedRFDChanged(previous, current) {
+		return nil
+	}
+
+	return _dataStore.CreateRevision(models.NewRFDRevision(current, source))
+}
+
+// storedRFDChanged compares two stored copies of an RFD
+func storedRFDChanged(a *models.RFD, b *models.RFD) bool {
+	if a.Title != b.Title ||
+		a.State != b.State ||
+		a.Discussion != b.Discussion ||
+		a.Public != b.Public ||
+		a.ContentMD != b.ContentMD {
+		return true
+	}
+
+	if strings.Join(a.Tags, ",") != strings.Join(b.Tags, ",") {
+		return true
+	}
+
+	return strings.Join(authorIDs(a.Authors), ",") != strings.Join(authorIDs(b.Authors), ",")
+}
+
+func authorIDs(authors []models.Author) []string {
+	ids := make([]string, 0, len(authors))
+	for _, author := range authors {
+		ids = append(ids, author.ID)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"sort"
@@ -15,12 +17,9 @@ import (
 	"github.com/geekgonecrazy/rfd-tool/config"
 	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/geekgonecrazy/rfd-tool/renderer"
-	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
-	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/storage/memory"
 	"gopkg.in/yaml.v2"
 )
 
@@ -39,12 +38,22 @@ func getRFDLock(rfdNum string) *sync.Mutex {
 	return rfdLocks[rfdNum]
 }
 
-func GetRFDs() ([]models.RFD, error) {
-	return _dataStore.GetRFDs()
-}
+// GetRFDs returns every RFD viewer can see
+func GetRFDs(viewer models.Viewer) ([]models.RFD, error) {
+	var rfds []models.RFD
+	var err error
+
+	if viewer.LoggedIn || viewer.All {
+		rfds, err = _dataStore.GetRFDs()
+	} else {
+		rfds, err = _dataStore.GetPublicRFDs()
+	}
 
-func GetPublicRFDs() ([]models.RFD, error) {
-	return _dataStore.GetPublicRFDs()
+	if err != nil {
+		return nil, err
+	}
+
+	return visibleRFDs(rfds, viewer), nil
 }
 
 func GetPublicRFDByID(id string) (*models.RFD, error) {
@@ -59,6 +68,7 @@ func GetPublicRFDByID(id string) (*models.RFD, error) {
 	return _dataStore.GetPublicRFDByID(id)
 }
 
+// IsRFDPublic reports whether anyone can see the RFD without signing in
 func IsRFDPublic(id string) (bool, error) {
 	if id != "" && !_validId.Match([]byte(id)) {
 		return false, nil
@@ -71,53 +81,83 @@ func IsRFDPublic(id string) (bool, error) {
 	return _dataStore.IsRFDPublic(id)
 }
 
-func GetPublicRFDsByTag(tag string) ([]models.RFD, error) {
-	return _dataStore.GetPublicRFDsByTag(tag)
-}
+// searchLimit is the most results a search returns
+const searchLimit = 50
 
-func GetPublicRFDsByAuthorID(authorID string) ([]models.RFD, error) {
-	// Get RFD IDs for this author
-	rfdIDs, err := _dataStore.GetRFDIDsByAuthor(authorID)
-	if err != nil {
-		return nil, err
-	}
+// SearchRFDs full text searches RFD titles and bodies, only returning RFDs viewer can see
+func SearchRFDs(query string, viewer models.Viewer) ([]models.SearchResult, error) {
+	opts := models.SearchOptions{PublicOnly: !viewer.LoggedIn && !viewer.All, Limit: searchLimit}
 
-	var publicRFDs []models.RFD
-	for _, rfdID := range rfdIDs {
-		// Check if RFD is public
-		isPublic, err := _dataStore.IsRFDPublic(rfdID)
+	// Restricted hits are dropped after the store's limit, so keep fetching pages until there are enough visible ones
+	visible := []models.SearchResult{}
+	for {
+		results, err := _dataStore.Search(strings.TrimSpace(query), opts)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		if !isPublic {
-			continue
+
+		for _, result := range results {
+			if result.RFD.VisibleTo(viewer) {
+				visible = append(visible, result)
+			}
+
+			if len(visible) == searchLimit {
+				return visible, nil
+			}
 		}
 
-		rfd, err := _dataStore.GetRFDByID(rfdID)
-		if err != nil {
-			continue
+		if len(results) < opts.Limit {
+			return visible, nil
 		}
 
-		publicRFDs = append(publicRFDs, *rfd)
+		opts.Offset += opts.Limit
 	}
-
-	return publicRFDs, nil
 }
 
 func GetAuthorByID(id string) (*models.Author, error) {
 	return _dataStore.GetAuthorByID(id)
 }
 
-func GetTags() ([]models.Tag, error) {
+// GetTags returns the tags on RFDs viewer can see, each listing only those RFDs
+func GetTags(viewer models.Viewer) ([]models.Tag, error) {
 	tags, err := _dataStore.GetTags()
 	if err != nil {
 		return nil, err
 	}
 
-	return tags, nil
+	rfds, err := _dataStore.GetRFDs()
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[string]bool)
+	for _, rfd := range visibleRFDs(rfds, viewer) {
+		visible[rfd.ID] = true
+	}
+
+	// Only list the RFDs viewer can see, and drop tags that leaves empty
+	visibleTags := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		rfdIDs := make([]string, 0, len(tag.RFDs))
+		for _, id := range tag.RFDs {
+			if visible[id] {
+				rfdIDs = append(rfdIDs, id)
+			}
+		}
+
+		if len(rfdIDs) == 0 {
+			continue
+		}
+
+		tag.RFDs = rfdIDs
+		visibleTags = append(visibleTags, tag)
+	}
+
+	return visibleTags, nil
 }
 
-func GetRFDsByAuthor(authorID string) ([]models.RFD, error) {
+// GetRFDsByAuthor returns the RFDs written by an author that viewer can see
+func GetRFDsByAuthor(authorID string, viewer models.Viewer) ([]models.RFD, error) {
 	// Validate that authorID is provided
 	if authorID == "" {
 		return nil, errors.New("author ID is required")
@@ -137,7 +177,10 @@ func GetRFDsByAuthor(authorID string) ([]models.RFD, error) {
 			continue
 		}
 
-		rfds = append(rfds, *rfd)
+		// Tags and author links can still point at RFDs that are gone
+		if rfd != nil && rfd.VisibleTo(viewer) {
+			rfds = append(rfds, *rfd)
+		}
 	}
 
 	return rfds, nil
@@ -207,27 +250,10 @@ func processRFDAuthors(authorStrings []string) ([]string, error) {
 // Priority: email > name
 // Auto-merges when finding existing authors
 func FindOrCreateAuthor(name, email string) (*models.Author, error) {
-	// 1. Parse and clean inputs
-	name = strings.TrimSpace(name)
-	email = strings.TrimSpace(email)
+	// 1. Parse and clean inputs, dropping invalid emails
+	name, email = cleanAuthor(name, email)
 
-	// If input looks like "Name <email>", parse it
-	if strings.Contains(name, "<") && strings.Contains(name, ">") {
-		parsedName, parsedEmail := models.ParseAuthor(name)
-		if parsedName != "" {
-			name = parsedName
-		}
-		if parsedEmail != "" {
-			email = parsedEmail
-		}
-	}
-
-	// 2. Validate email format
-	if email != "" && !strings.Contains(email, "@") {
-		email = ""
-	}
-
-	// 3. Search by email first (most unique)
+	// 2. Search by email first (most unique)
 	if email != "" {
 		author, err := _dataStore.GetAuthorByEmail(email)
 		if err != nil {
@@ -245,7 +271,7 @@ func FindOrCreateAuthor(name, email string) (*models.Author, error) {
 		}
 	}
 
-	// 4. Search by name if no email match (exact match only - no partial matching)
+	// 3. Search by name if no email match (exact match only - no partial matching)
 	if name != "" {
 		author, err := _dataStore.GetAuthorByName(name)
 		if err != nil {
@@ -263,7 +289,7 @@ func FindOrCreateAuthor(name, email string) (*models.Author, error) {
 		}
 	}
 
-	// 5. No match found - create new author
+	// 4. No match found - create new author
 	// Don't create author with no identifying information
 	if name == "" && email == "" {
 		return nil, fmt.Errorf("cannot create author with no name or email")
@@ -281,7 +307,51 @@ func FindOrCreateAuthor(name, email string) (*models.Author, error) {
 	return author, nil
 }
 
-func GetRFDsByTag(tag string) ([]models.RFD, error) {
+// cleanAuthor parses "Name <email>" out of name and drops emails that aren't valid
+func cleanAuthor(name, email string) (string, string) {
+	name = strings.TrimSpace(name)
+	email = strings.TrimSpace(email)
+
+	// If input looks like "Name <email>", parse it
+	if strings.Contains(name, "<") && strings.Contains(name, ">") {
+		parsedName, parsedEmail := models.ParseAuthor(name)
+		if parsedName != "" {
+			name = parsedName
+		}
+		if parsedEmail != "" {
+			email = parsedEmail
+		}
+	}
+
+	// Validate email format
+	if email != "" && !strings.Contains(email, "@") {
+		email = ""
+	}
+
+	return name, email
+}
+
+// findAuthor looks an author up the same way FindOrCreateAuthor does, email then name,
+// without creating or updating anything. Returns nil if there's no match.
+func findAuthor(name, email string) (*models.Author, error) {
+	name, email = cleanAuthor(name, email)
+
+	if email != "" {
+		author, err := _dataStore.GetAuthorByEmail(email)
+		if err != nil || author != nil {
+			return author, err
+		}
+	}
+
+	if name != "" {
+		return _dataStore.GetAuthorByName(name)
+	}
+
+	return nil, nil
+}
+
+// GetRFDsByTag returns the RFDs with a tag that viewer can see
+func GetRFDsByTag(tag string, viewer models.Viewer) ([]models.RFD, error) {
 	t, err := _dataStore.GetTag(tag)
 	if err != nil {
 		return nil, err
@@ -298,13 +368,17 @@ func GetRFDsByTag(tag string) ([]models.RFD, error) {
 			return nil, err
 		}
 
-		rfds = append(rfds, *rfd)
+		// Tags and author links can still point at RFDs that are gone
+		if rfd != nil && rfd.VisibleTo(viewer) {
+			rfds = append(rfds, *rfd)
+		}
 	}
 
 	return rfds, nil
 }
 
-func GetRFDByID(id string) (*models.RFD, error) {
+// GetRFDByID gets a single RFD, "latest" is the highest numbered RFD viewer can see
+func GetRFDByID(id string, viewer models.Viewer) (*models.RFD, error) {
 	if id == "" {
 		return nil, errors.New("no id provided")
 	}
@@ -315,14 +389,15 @@ func GetRFDByID(id string) (*models.RFD, error) {
 		if err != nil {
 			return nil, err
 		}
-		if len(rfds) == 0 {
-			return nil, errors.New("no RFDs found")
-		}
 
-		// Find the highest ID
+		// Find the highest ID, skipping restricted RFDs so they can't hide the newest one viewer can see
 		var maxID int64 = 0
 		var latestID string
 		for _, rfd := range rfds {
+			if !rfd.VisibleTo(viewer) {
+				continue
+			}
+
 			if idNum, err := strconv.ParseInt(rfd.ID, 10, 64); err == nil && idNum > maxID {
 				maxID = idNum
 				latestID = rfd.ID
@@ -330,7 +405,7 @@ func GetRFDByID(id string) (*models.RFD, error) {
 		}
 
 		if latestID == "" {
-			return nil, errors.New("no valid RFD IDs found")
+			return nil, nil
 		}
 		id = latestID
 	}
@@ -338,279 +413,573 @@ func GetRFDByID(id string) (*models.RFD, error) {
 	return _dataStore.GetRFDByID(id)
 }
 
-func CreateRFD(newRFD *models.RFDCreatePayload) (*models.RFD, error) {
-	rfdNum, err := _dataStore.GetNextRFDID()
-	if err != nil {
-		return nil, err
+// maxRFDNumberAttempts is how many numbers CreateRFD goes through before giving up
+const maxRFDNumberAttempts = 5
+
+// errRFDNumberTaken is returned when a reserved RFD number turns out to be in use in the repo
+var errRFDNumberTaken = errors.New("rfd number already taken")
+
+// CreateRFD creates a new RFD from the template and pushes it to its own branch.
+// actor is who asked for it, recorded in the RFD's revision history.
+// If another server got the number into the repo first it tries again with the next one.
+func CreateRFD(newRFD *models.RFDCreatePayload, actor string) (*models.RFD, error) {
+	for attempt := 1; ; attempt++ {
+		rfd, err := createRFD(newRFD, actor)
+		if errors.Is(err, errRFDNumberTaken) && attempt < maxRFDNumberAttempts {
+			log.Printf("%v, trying the next number", err)
+			continue
+		}
+
+		return rfd, err
 	}
+}
 
-	storage := memory.NewStorage()
-	wt := memfs.New()
+func createRFD(newRFD *models.RFDCreatePayload, actor string) (*models.RFD, error) {
+	var rfdNum string
+	var commitHash plumbing.Hash
+	var renderedRFD *models.RFD
+	err := _repoCache.withWorktree(func(r *git.Repository, worktree *git.Worktree) error {
+		// Pick the template first so a bad template name doesn't use up a number
+		mainCommit, err := remoteBranchCommit(r, config.Config.Repo.MainBranch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", config.Config.Repo.MainBranch, err)
+		}
+
+		templates, err := readRFDTemplates(mainCommit)
+		if err != nil {
+			return err
+		}
+
+		template, err := findRFDTemplate(templates, newRFD.Template)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Getting template %s", template.Path)
+		templateContent, err := readCommitFile(mainCommit, template.Path)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Parsing frontmatter off of template")
+		_, body, err := parseRFDTemplate(template.Path, templateContent)
+		if err != nil {
+			return err
+		}
+
+		rfdNum, err = reserveRFDNumber(r)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Reserved RFD number %s", rfdNum)
+		worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", rfdNum)), Create: true})
+
+		log.Println("Making directory for new RFD")
+		rfdFolder := fmt.Sprintf("%s/%s", config.Config.Repo.Folder, rfdNum)
+		if err := worktree.Filesystem.MkdirAll(rfdFolder, fs.ModePerm); err != nil {
+			return err
+		}
+
+		// The template's tags, state and public flag are the defaults, tags from the request are added on top
+		rfdMeta := models.RFDMetaYAML{
+			Title:   newRFD.Title,
+			Authors: splitList(newRFD.Authors),
+			State:   template.State,
+			Tags:    mergeTags(template.Tags, splitList(newRFD.Tags)),
+			Public:  template.Public,
+		}
+
+		log.Println("Constructing RFD file including frontmatter")
+		rfdFile, err := buildRFDFile(rfdMeta, body)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Creating RFD file on worktree")
+		f, err := worktree.Filesystem.Create(fmt.Sprintf("%s/README.md", rfdFolder))
+		if err != nil {
+			return err
+		}
+
+		log.Println("Writing RFD contents to file in worktree")
+		_, err = f.Write(rfdFile)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Adding new RFD to be committed")
+		_, err = worktree.Add(rfdFolder)
+		if err != nil {
+			return err
+		}
 
-	log.Println("Cloning RFD Repo")
-	r, err := git.Clone(storage, wt, _gitCloneOptions)
+		log.Println("Get Author Signature")
+		author := object.Signature{
+			Name:  config.Config.Repo.CommitAuthorName,
+			Email: config.Config.Repo.CommitAuthorEmail,
+			When:  time.Now(),
+		}
+
+		commitMsg := fmt.Sprintf("Creating RFD %s", rfdNum)
+
+		log.Println("Committing: ", commitMsg)
+		commitHash, err = worktree.Commit(commitMsg, &git.CommitOptions{Author: &author})
+		if err != nil {
+			return err
+		}
+
+		rf, err := worktree.Filesystem.Open(fmt.Sprintf("%s/README.md", rfdFolder))
+		if err != nil {
+			return err
+		}
+
+		log.Println("Rendering RFD to store internally")
+		renderedRFD, err = renderer.RenderRFD(rfdNum, rf)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Pushing RFD to remote")
+		if err := _repoCache.pushBranch(r, rfdNum); err != nil {
+			// Another server may have pushed the same number since we fetched
+			if taken, listErr := _repoCache.remoteHasBranch(rfdNum); listErr == nil && taken {
+				return fmt.Errorf("%w: %s was pushed by someone else", errRFDNumberTaken, rfdNum)
+			}
+
+			return fmt.Errorf("failed to push: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Getting worktree")
-	worktree, err := r.Worktree()
-	if err != nil {
+	// Only stored once it's in the repo, so a lost race doesn't leave a stray RFD behind
+	if err := CreateOrUpdateRFD(renderedRFD, false, &models.ChangeSource{Actor: actor, CommitSHA: commitHash.String()}); err != nil {
 		return nil, err
 	}
 
-	worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", rfdNum)), Create: true})
+	openRFDPullRequestAsync(rfdNum)
 
-	log.Println("Making directory for new RFD")
-	rfdFolder := fmt.Sprintf("%s/%s", config.Config.Repo.Folder, rfdNum)
-	if err := worktree.Filesystem.MkdirAll(rfdFolder, fs.ModePerm); err != nil {
-		return nil, err
-	}
+	return renderedRFD, nil
+}
 
-	log.Println("Getting TEMPLATE.md")
-	template, err := worktree.Filesystem.Open("TEMPLATE.md")
+// reserveRFDNumber reserves the next RFD number in the store, skipping any that
+// already have a branch or folder in the repo. The store can be behind the repo
+// when the database is stale or another server created RFDs it hasn't synced yet.
+func reserveRFDNumber(r *git.Repository) (string, error) {
+	taken, err := takenRFDNumbers(r)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	rfdMeta := models.RFDMeta{
-		Tags: []string{},
-	}
+	for attempt := 1; attempt <= maxRFDNumberAttempts; attempt++ {
+		rfdNum, err := _dataStore.ReserveNextRFDID()
+		if err != nil {
+			return "", err
+		}
 
-	log.Println("Parsing frontmatter off of template")
-	body, err := frontmatter.Parse(template, &rfdMeta)
-	if err != nil {
-		return nil, err
-	}
+		if !taken[rfdNum] {
+			existing, err := _dataStore.GetRFDByID(rfdNum)
+			if err != nil {
+				return "", err
+			}
 
-	// Create a temporary struct for YAML serialization that uses string authors
-	type yamlRFDMeta struct {
-		Title      string   `yaml:"title"`
-		Authors    []string `yaml:"authors"`
-		State      string   `yaml:"state"`
-		Discussion string   `yaml:"discussion"`
-		Tags       []string `yaml:"tags"`
-		Public     bool     `yaml:"public"`
-	}
+			if existing == nil {
+				return rfdNum, nil
+			}
+		}
 
-	yamlMeta := yamlRFDMeta{
-		Title:      newRFD.Title,
-		Authors:    strings.Split(newRFD.Authors, ","),
-		State:      string(models.Ideation),
-		Discussion: rfdMeta.Discussion,
-		Public:     rfdMeta.Public,
-	}
+		log.Printf("RFD %s already exists, skipping it", rfdNum)
+
+		// Jump past everything in the repo instead of going through it one number at a time
+		highest := ""
+		for num := range taken {
+			if num > highest {
+				highest = num
+			}
+		}
 
-	// Something to do with the split seems to cause it to put an empty set of quotes here if we don't do this
-	if newRFD.Tags != "" {
-		yamlMeta.Tags = strings.Split(newRFD.Tags, ",")
+		if highest != "" {
+			if err := _dataStore.RaiseNextRFDID(highest); err != nil {
+				return "", err
+			}
+		}
 	}
 
-	rfdSeperater := []byte(`---
-`)
+	return "", fmt.Errorf("%w: no free number after %d attempts", errRFDNumberTaken, maxRFDNumberAttempts)
+}
+
+// takenRFDNumbers returns every RFD number with a branch on the remote or a folder on main
+func takenRFDNumbers(r *git.Repository) (map[string]bool, error) {
+	taken := map[string]bool{}
 
-	log.Println("Marshalling RFD Meta Frontmatter")
-	header, err := yaml.Marshal(yamlMeta)
+	refs, err := r.References()
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Constructing RFD file including frontmatter")
-	rfdFile := []byte{}
-	rfdFile = append(rfdFile, rfdSeperater...)
-	rfdFile = append(rfdFile, header...)
-	rfdFile = append(rfdFile, rfdSeperater...)
-	rfdFile = append(rfdFile, body...)
-
-	log.Println("Creating RFD file on worktree")
-	f, err := worktree.Filesystem.Create(fmt.Sprintf("%s/README.md", rfdFolder))
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() {
+			if branch := strings.TrimPrefix(ref.Name().Short(), "origin/"); _rfdBranch.MatchString(branch) {
+				taken[branch] = true
+			}
+		}
+		return nil
+	})
+	refs.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Writing RFD contents to file in worktree")
-	_, err = f.Write(rfdFile)
+	mainCommit, err := remoteBranchCommit(r, config.Config.Repo.MainBranch)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to resolve %s: %w", config.Config.Repo.MainBranch, err)
 	}
 
-	log.Println("Adding new RFD to be committed")
-	_, err = worktree.Add(rfdFolder)
+	mainTree, err := mainCommit.Tree()
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Get Author Signature")
-	author := object.Signature{
-		Name:  config.Config.Repo.CommitAuthorName,
-		Email: config.Config.Repo.CommitAuthorEmail,
-		When:  time.Now(),
+	folderTree, err := mainTree.Tree(config.Config.Repo.Folder)
+	if err == object.ErrDirectoryNotFound {
+		return taken, nil
 	}
-
-	commitMsg := fmt.Sprintf("Creating RFD %s", rfdNum)
-
-	log.Println("Committing: ", commitMsg)
-	_, err = worktree.Commit(commitMsg, &git.CommitOptions{Author: &author})
 	if err != nil {
 		return nil, err
 	}
 
-	rf, err := worktree.Filesystem.Open(fmt.Sprintf("%s/README.md", rfdFolder))
-	if err != nil {
-		return nil, err
+	for _, entry := range folderTree.Entries {
+		if !entry.Mode.IsFile() && _rfdBranch.MatchString(entry.Name) {
+			taken[entry.Name] = true
+		}
 	}
 
-	log.Println("Rendering RFD to store internally")
-	renderedRFD, err := renderer.RenderRFD(rfdNum, rf)
+	return taken, nil
+}
+
+// UpdateRFDDiscussionInRepo updates the discussion field in the RFD's frontmatter and commits to git
+func UpdateRFDDiscussionInRepo(rfdNum string, discussionURL string) error {
+	_, err := commitRFDChange(rfdNum, rfdChange{
+		Message:       fmt.Sprintf("Add discussion link for RFD %s", rfdNum),
+		SkipUnchanged: true,
+		Update: func(rfdMeta *models.RFDMetaYAML, body []byte) []byte {
+			rfdMeta.Discussion = discussionURL
+			return body
+		},
+	})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		log.Printf("Discussion link for RFD %s is already in the repo", rfdNum)
+		return nil
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if err := CreateOrUpdateRFD(renderedRFD, false); err != nil {
+	log.Printf("Successfully updated discussion link for RFD %s in repo", rfdNum)
+	return nil
+}
+
+// ErrEditConflict is returned when an RFD's README.md changed since the commit an edit was based on
+var ErrEditConflict = errors.New("rfd was changed by someone else")
+
+// rfdChange is a change to an RFD's README.md made by commitRFDChange
+type rfdChange struct {
+	Message string
+
+	// Author of the commit, defaults to the configured commit author
+	Author *object.Signature
+
+	// BaseCommit is the commit the change was made against, if known.
+	// The change is refused if README.md changed on the branch since then.
+	BaseCommit string
+
+	// CreateBranch puts the change on a new NNNN branch off main when the RFD doesn't have one yet
+	CreateBranch bool
+
+	// MergeIntoMain merges the RFD's branch into main after the change is committed
+	MergeIntoMain bool
+
+	// SkipUnchanged returns git.ErrEmptyCommit instead of committing when README.md is left as it was
+	SkipUnchanged bool
+
+	// Update changes the frontmatter in place and returns the new body
+	Update func(rfdMeta *models.RFDMetaYAML, body []byte) []byte
+}
+
+// rfdCheckout is the RFD repo checked out where an RFD's changes go
+type rfdCheckout struct {
+	Repo     *git.Repository
+	Worktree *git.Worktree
+
+	// HasBranch is true if the RFD has an NNNN branch on the remote
+	HasBranch bool
+
+	// Merged is true if everything on the RFD's branch is already on main
+	Merged bool
+
+	// OnBranch is true if the RFD's branch is checked out, otherwise it's main
+	OnBranch bool
+}
+
+// checkoutRFD checks out the RFD's branch, or leaves main checked out if it has none
+// or it has already been merged. With createBranch the branch is created off main
+// (or moved up to main if it was merged) so the change still goes on the branch.
+func checkoutRFD(r *git.Repository, worktree *git.Worktree, rfdNum string, createBranch bool) (*rfdCheckout, error) {
+	checkout := &rfdCheckout{Repo: r, Worktree: worktree}
+
+	branchRef := plumbing.NewBranchReferenceName(rfdNum)
+
+	err := trackRemoteBranch(r, rfdNum)
+	if err != nil && err != plumbing.ErrReferenceNotFound {
 		return nil, err
 	}
 
-	log.Println("Pushing RFD to remote")
-	if err := r.Push(&git.PushOptions{RemoteName: "origin", Auth: _gitPublicKeys}); err != nil {
-		return nil, err
+	checkout.HasBranch = err == nil
+
+	if checkout.HasBranch {
+		checkout.Merged, err = branchMerged(r, branchRef)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return renderedRFD, nil
+	switch {
+	case checkout.HasBranch && !checkout.Merged:
+		log.Printf("Checking out branch %s", rfdNum)
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+			log.Printf("Failed to checkout branch %s: %v (will use main)", rfdNum, err)
+		} else {
+			checkout.OnBranch = true
+		}
+	case checkout.HasBranch && createBranch:
+		head, err := r.Head()
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("RFD %s branch already merged, moving it up to %s", rfdNum, head.Name().Short())
+		if err := r.Storer.SetReference(plumbing.NewHashReference(branchRef, head.Hash())); err != nil {
+			return nil, fmt.Errorf("failed to move branch: %w", err)
+		}
+
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+			return nil, fmt.Errorf("failed to checkout branch: %w", err)
+		}
+		checkout.OnBranch = true
+	case checkout.HasBranch:
+		log.Printf("RFD %s branch already merged, updating on main branch", rfdNum)
+	case createBranch:
+		log.Printf("RFD %s branch not found, creating it from main", rfdNum)
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true}); err != nil {
+			return nil, fmt.Errorf("failed to create branch: %w", err)
+		}
+		checkout.OnBranch = true
+	default:
+		log.Printf("RFD %s branch not found, updating on main branch", rfdNum)
+	}
+
+	return checkout, nil
 }
 
-// UpdateRFDDiscussionInRepo updates the discussion field in the RFD's frontmatter and commits to git
-func UpdateRFDDiscussionInRepo(rfdNum string, discussionURL string) error {
-	storage := memory.NewStorage()
-	wt := memfs.New()
+// rfdCommit is the result of commitRFDChange
+type rfdCommit struct {
+	Commit plumbing.Hash
+
+	// File is the updated README.md
+	File []byte
 
-	log.Printf("Cloning RFD Repo to update discussion link for RFD %s", rfdNum)
-	r, err := git.Clone(storage, wt, _gitCloneOptions)
+	// Merge is set if the change merged the RFD's branch into main
+	Merge *rfdMergeResult
+}
+
+// commitRFDChange rewrites the RFD's README.md on its branch (or main if it has none),
+// commits and pushes.
+func commitRFDChange(rfdNum string, change rfdChange) (*rfdCommit, error) {
+	var result *rfdCommit
+	err := _repoCache.withWorktree(func(r *git.Repository, worktree *git.Worktree) error {
+		var err error
+		result, err = commitRFDChangeOnWorktree(r, worktree, rfdNum, change)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to clone repo: %w", err)
+		return nil, err
 	}
 
-	worktree, err := r.Worktree()
+	return result, nil
+}
+
+func commitRFDChangeOnWorktree(r *git.Repository, worktree *git.Worktree, rfdNum string, change rfdChange) (*rfdCommit, error) {
+	checkout, err := checkoutRFD(r, worktree, rfdNum, change.CreateBranch)
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return nil, err
 	}
 
-	// Try to fetch and checkout the RFD's branch if it exists, otherwise use main
-	branchRef := plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", rfdNum))
-
-	// Fetch the specific branch
-	log.Printf("Fetching branch %s", rfdNum)
-	err = r.Fetch(&git.FetchOptions{
-		RemoteName: "origin",
-		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", rfdNum, rfdNum))},
-		Auth:       _gitPublicKeys,
-	})
+	wt := worktree.Filesystem
 
-	branchExists := err == nil || err == git.NoErrAlreadyUpToDate
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		log.Printf("Could not fetch branch %s: %v (will use main)", rfdNum, err)
-	}
+	rfdPath := fmt.Sprintf("%s/%s/README.md", config.Config.Repo.Folder, rfdNum)
 
-	if branchExists {
-		// Checkout the fetched branch
-		log.Printf("Checking out branch %s", rfdNum)
-		err = worktree.Checkout(&git.CheckoutOptions{
-			Branch: branchRef,
-			Force:  true,
-		})
-		if err != nil {
-			log.Printf("Failed to checkout branch %s: %v (will use main)", rfdNum, err)
-			branchExists = false
+	if change.BaseCommit != "" {
+		if err := checkEditBase(r, change.BaseCommit, rfdPath); err != nil {
+			return nil, err
 		}
 	}
 
-	if !branchExists {
-		log.Printf("RFD %s branch not found, updating on main branch", rfdNum)
-	}
-
 	// Read the RFD file
-	rfdPath := fmt.Sprintf("%s/%s/README.md", config.Config.Repo.Folder, rfdNum)
 	f, err := wt.Open(rfdPath)
 	if err != nil {
-		return fmt.Errorf("failed to open RFD file: %w", err)
+		return nil, fmt.Errorf("failed to open RFD file: %w", err)
+	}
+
+	original, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RFD file: %w", err)
 	}
 
 	// Parse frontmatter
 	var rfdMeta models.RFDMetaYAML
-	body, err := frontmatter.Parse(f, &rfdMeta)
-	f.Close()
+	body, err := frontmatter.Parse(bytes.NewReader(original), &rfdMeta)
 	if err != nil {
-		return fmt.Errorf("failed to parse frontmatter: %w", err)
+		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
 	}
 
-	// Update the discussion field
-	rfdMeta.Discussion = discussionURL
+	body = change.Update(&rfdMeta, body)
 
-	// Rebuild the file with updated frontmatter
-	rfdSeparator := []byte("---\n")
-	header, err := yaml.Marshal(rfdMeta)
+	rfdFile, err := buildRFDFile(rfdMeta, body)
 	if err != nil {
-		return fmt.Errorf("failed to marshal frontmatter: %w", err)
+		return nil, err
 	}
 
-	rfdFile := []byte{}
-	rfdFile = append(rfdFile, rfdSeparator...)
-	rfdFile = append(rfdFile, header...)
-	rfdFile = append(rfdFile, rfdSeparator...)
-	rfdFile = append(rfdFile, body...)
+	if change.SkipUnchanged && bytes.Equal(rfdFile, original) {
+		return nil, git.ErrEmptyCommit
+	}
 
 	// Write the updated file
 	newFile, err := wt.Create(rfdPath)
 	if err != nil {
-		return fmt.Errorf("failed to create RFD file: %w", err)
+		return nil, fmt.Errorf("failed to create RFD file: %w", err)
 	}
 
 	_, err = newFile.Write(rfdFile)
 	newFile.Close()
 	if err != nil {
-		return fmt.Errorf("failed to write RFD file: %w", err)
+		return nil, fmt.Errorf("failed to write RFD file: %w", err)
 	}
 
 	// Stage the change
 	_, err = worktree.Add(rfdPath)
 	if err != nil {
-		return fmt.Errorf("failed to stage changes: %w", err)
+		return nil, fmt.Errorf("failed to stage changes: %w", err)
 	}
 
 	// Commit
-	author := object.Signature{
+	committer := object.Signature{
 		Name:  config.Config.Repo.CommitAuthorName,
 		Email: config.Config.Repo.CommitAuthorEmail,
 		When:  time.Now(),
 	}
 
-	commitMsg := fmt.Sprintf("Add discussion link for RFD %s", rfdNum)
-	_, err = worktree.Commit(commitMsg, &git.CommitOptions{Author: &author})
+	author := &committer
+	if change.Author != nil {
+		author = change.Author
+	}
+
+	commitHash, err := worktree.Commit(change.Message, &git.CommitOptions{Author: author, Committer: &committer})
 	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	result := &rfdCommit{Commit: commitHash, File: rfdFile}
+
+	// Merge before pushing so a conflict leaves the remote untouched
+	if change.MergeIntoMain && checkout.OnBranch {
+		result.Merge, err = mergeRFDBranch(r, worktree, rfdNum)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Push
-	log.Printf("Pushing discussion link update for RFD %s", rfdNum)
-	if err := r.Push(&git.PushOptions{RemoteName: "origin", Auth: _gitPublicKeys}); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	log.Printf("Pushing update for RFD %s", rfdNum)
+	if err := _repoCache.push(r); err != nil {
+		return nil, fmt.Errorf("failed to push: %w", err)
+	}
+
+	return result, nil
+}
+
+// checkEditBase makes sure the file at path hasn't changed between base and HEAD.
+// Changes to anything else on the branch don't conflict with an edit.
+func checkEditBase(r *git.Repository, base string, path string) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	if head.Hash().String() == base {
+		return nil
+	}
+
+	baseCommit, err := r.CommitObject(plumbing.NewHash(base))
+	if err != nil {
+		return fmt.Errorf("%w: base commit %s not found", ErrEditConflict, base)
+	}
+
+	headCommit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+
+	baseFile, err := baseCommit.File(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s not found at %s", ErrEditConflict, path, base)
+	}
+
+	headFile, err := headCommit.File(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s not found at %s", ErrEditConflict, path, head.Hash())
+	}
+
+	if baseFile.Hash != headFile.Hash {
+		return fmt.Errorf("%w: %s changed in %s", ErrEditConflict, path, head.Hash().String()[:7])
 	}
 
-	log.Printf("Successfully updated discussion link for RFD %s in repo", rfdNum)
 	return nil
 }
 
-func GetRFDCodespaceLink(rfdNum string) (string, error) {
-	repo := config.Config.Repo.URL
-	folder := config.Config.Repo.Folder
+// buildRFDFile puts frontmatter back on top of an RFD's body
+func buildRFDFile(rfdMeta models.RFDMetaYAML, body []byte) ([]byte, error) {
+	rfdSeparator := []byte("---\n")
+	header, err := yaml.Marshal(rfdMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal frontmatter: %w", err)
+	}
+
+	rfdFile := []byte{}
+	rfdFile = append(rfdFile, rfdSeparator...)
+	rfdFile = append(rfdFile, header...)
+	rfdFile = append(rfdFile, rfdSeparator...)
+	rfdFile = append(rfdFile, body...)
+
+	return rfdFile, nil
+}
 
-	// https://github.dev/geekgonecrazy/rfd-example/blob/0005/rfds/0005/README.md
-	githubDevLink := strings.Replace(repo, "github.com", "github.dev", 1)
-	githubDevLink = fmt.Sprintf("%s/blob/%s/%s/%s/README.md", githubDevLink, rfdNum, folder, rfdNum)
+// GetRFDEditOnlineLink links to editing the RFD on its branch in the forge's online editor.
+// Empty if the forge doesn't have one.
+func GetRFDEditOnlineLink(rfdNum string) string {
+	return _forge.EditURL(rfdNum, rfdReadmePath(rfdNum))
+}
 
-	return githubDevLink, nil
+// GetRFDBranchLink links to the RFD's branch on the forge, empty if it has no web UI
+func GetRFDBranchLink(rfdNum string) string {
+	return _forge.BranchURL(rfdNum)
 }
 
-func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool) error {
+func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool, source *models.ChangeSource) error {
 	// Make a copy of existing for webhook comparison
 	oldCopy := *existing
 
@@ -633,6 +1002,10 @@ func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool) e
 		return fmt.Errorf("failed to update authors for RFD: %w", err)
 	}
 
+	if err := recordRevision(existing, updated.ID, source); err != nil {
+		log.Printf("Failed to record revision for RFD %s: %v", updated.ID, err)
+	}
+
 	// Send webhook for update (only if there are changes and not skipping discussion)
 	if _webhookClient != nil && !skipDiscussion {
 		resp, err := _webhookClient.SendUpdated(&oldCopy, updated)
@@ -646,12 +1019,8 @@ func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool) e
 				if err := _dataStore.UpdateRFD(updated); err != nil {
 					log.Printf("Failed to update RFD %s with discussion URL: %v", updated.ID, err)
 				} else {
-					// Commit the discussion link to git
-					go func(rfdID, discussionURL string) {
-						if err := UpdateRFDDiscussionInRepo(rfdID, discussionURL); err != nil {
-							log.Printf("Failed to commit discussion URL for RFD %s: %v", rfdID, err)
-						}
-					}(updated.ID, resp.Discussion.URL)
+					// Queue the discussion link commit, retried until it lands in git
+					queueDiscussionLinkCommit(updated.ID, resp.Discussion.URL)
 				}
 			}
 		}
@@ -666,21 +1035,7 @@ func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool) e
 		}
 
 		if !keep {
-			tag, err := _dataStore.GetTag(t)
-			if err != nil {
-				return err
-			}
-
-			rfds := []string{}
-			for _, r := range tag.RFDs {
-				if r != updated.ID {
-					rfds = append(rfds, r)
-				}
-			}
-
-			sort.Strings(tag.RFDs)
-
-			if err := _dataStore.UpdateTag(tag); err != nil {
+			if err := removeRFDFromTag(t, updated.ID); err != nil {
 				return err
 			}
 		}
@@ -729,7 +1084,53 @@ func updateRFD(existing *models.RFD, updated *models.RFD, skipDiscussion bool) e
 	return nil
 }
 
-func CreateOrUpdateRFD(rfd *models.RFD, skipDiscussion bool) error {
+// removeRFDFromTag takes the RFD out of the tag's list
+func removeRFDFromTag(name string, rfdID string) error {
+	tag, err := _dataStore.GetTag(name)
+	if err != nil {
+		return err
+	}
+
+	if tag == nil {
+		return nil
+	}
+
+	rfds := []string{}
+	for _, r := range tag.RFDs {
+		if r != rfdID {
+			rfds = append(rfds, r)
+		}
+	}
+
+	tag.RFDs = rfds
+	sort.Strings(tag.RFDs)
+
+	return _dataStore.UpdateTag(tag)
+}
+
+// deleteRFD removes an RFD that was deleted from the repo
+func deleteRFD(rfdNum string) error {
+	lock := getRFDLock(rfdNum)
+	lock.Lock()
+	defer lock.Unlock()
+
+	existing, err := _dataStore.GetRFDByID(rfdNum)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	for _, t := range existing.Tags {
+		if err := removeRFDFromTag(t, rfdNum); err != nil {
+			return err
+		}
+	}
+
+	return _dataStore.DeleteRFD(rfdNum)
+}
+
+// CreateOrUpdateRFD stores an RFD, recording a revision if anything changed.
+// source says where the change came from and may be nil if unknown.
+func CreateOrUpdateRFD(rfd *models.RFD, skipDiscussion bool, source *models.ChangeSource) error {
 	if rfd.ID != "" && !_validId.Match([]byte(rfd.ID)) {
 		return errors.New("invalid rfd id")
 	}
@@ -741,15 +1142,30 @@ func CreateOrUpdateRFD(rfd *models.RFD, skipDiscussion bool) error {
 
 	// Normalize tags
 	rfd.Tags = normalizeTags(rfd.Tags)
+	rfd.Visibility = normalizeVisibility(rfd.Visibility)
 
 	existingRFD, err := _dataStore.GetRFDByID(rfd.ID)
 	if err != nil {
 		return err
 	}
 
+	transition, err := checkStateTransition(existingRFD, rfd, source)
+	if err != nil {
+		return err
+	}
+
 	if existingRFD != nil {
 		// Don't clear AuthorStrings here - updateRFD will process them
-		return updateRFD(existingRFD, rfd, skipDiscussion)
+		if err := updateRFD(existingRFD, rfd, skipDiscussion, source); err != nil {
+			return err
+		}
+
+		if _, err := syncRFDRelations(rfd); err != nil {
+			log.Printf("Failed to update relations for RFD %s: %v", rfd.ID, err)
+		}
+
+		recordStateTransition(transition)
+		return nil
 	}
 
 	// Process authors from AuthorStrings for new RFDs
@@ -771,6 +1187,16 @@ func CreateOrUpdateRFD(rfd *models.RFD, skipDiscussion bool) error {
 		return fmt.Errorf("failed to link authors to RFD: %w", err)
 	}
 
+	if _, err := syncRFDRelations(rfd); err != nil {
+		log.Printf("Failed to store relations for RFD %s: %v", rfd.ID, err)
+	}
+
+	if err := recordRevision(nil, rfd.ID, source); err != nil {
+		log.Printf("Failed to record revision for RFD %s: %v", rfd.ID, err)
+	}
+
+	recordStateTransition(transition)
+
 	// Send webhook for new RFD and handle discussion URL response
 	// Skip if skipDiscussion is true (bulk import mode)
 	if _webhookClient != nil && !skipDiscussion {
@@ -785,12 +1211,8 @@ func CreateOrUpdateRFD(rfd *models.RFD, skipDiscussion bool) error {
 				if err := _dataStore.UpdateRFD(rfd); err != nil {
 					log.Printf("Failed to update RFD %s with discussion URL: %v", rfd.ID, err)
 				} else {
-					// Commit the discussion link to git
-					go func(rfdID, discussionURL string) {
-						if err := UpdateRFDDiscussionInRepo(rfdID, discussionURL); err != nil {
-							log.Printf("Failed to commit discussion URL for RFD %s: %v", rfdID, err)
-						}
-					}(rfd.ID, resp.Discussion.URL)
+					// Queue the discussion link commit, retried until it lands in git
+					queueDiscussionLinkCommit(rfd.ID, resp.Discussion.URL)
 				}
 			} else {
 				log.Printf("Discussion link for RFD %s already set, skipping update", rfd.ID)
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/renderer"
+)
+
+// ErrIllegalStateTransition is returned when states.enforce is on and an RFD moves somewhere it isn't allowed to
+var ErrIllegalStateTransition = errors.New("illegal state transition")
+
+// GetRFDStateTransitions returns every state change of an RFD, oldest first
+func GetRFDStateTransitions(id string) ([]models.StateTransition, error) {
+	if id == "" {
+		return nil, errors.New("no id provided")
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	return _dataStore.GetStateTransitions(id)
+}
+
+// ErrInvalidState is returned when asked to move an RFD to a state that doesn't exist
+var ErrInvalidState = errors.New("invalid state")
+
+// GetNextRFDStates returns the states an RFD may be moved to from its current one
+func GetNextRFDStates(rfd *models.RFD) []models.RFDState {
+	return config.Config.States.AllowedTransitions()[rfd.State]
+}
+
+// ChangeRFDState rewrites state: in the RFD's README.md, pushes it, then stores the result.
+// actor is who asked for the change. Returns nil if there's no RFD with id that viewer can see.
+func ChangeRFDState(id string, state models.RFDState, actor string, viewer models.Viewer) (*models.RFD, error) {
+	if !state.Valid() {
+		return nil, fmt.Errorf("%w: '%s'", ErrInvalidState, state)
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	existing, err := GetRFDByID(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil || !existing.VisibleTo(viewer) {
+		return nil, nil
+	}
+
+	if existing.State == state {
+		return existing, nil
+	}
+
+	// Check before committing anything so an illegal move never reaches the repo
+	if _, err := checkStateTransition(existing, &models.RFD{ID: existing.ID, RFDMeta: models.RFDMeta{State: state}}, nil); err != nil {
+		return nil, err
+	}
+
+	commitMsg := fmt.Sprintf("Move RFD %s to %s\n\nRequested by %s", existing.ID, state, actor)
+
+	// Publishing merges the RFD's branch, refused on conflicts before anything is pushed
+	commit, err := commitRFDChange(existing.ID, rfdChange{
+		Message:       commitMsg,
+		MergeIntoMain: mergesIntoMain(state),
+		Update: func(rfdMeta *models.RFDMetaYAML, body []byte) []byte {
+			rfdMeta.State = state
+			return body
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderer.RenderRFD(existing.ID, bytes.NewReader(commit.File))
+	if err != nil {
+		return nil, err
+	}
+
+	// The discussion link may still be on its way into git, don't wipe it out
+	if rendered.Discussion == "" {
+		rendered.Discussion = existing.Discussion
+	}
+
+	if err := CreateOrUpdateRFD(rendered, false, &models.ChangeSource{Actor: actor, CommitSHA: commit.Commit.String()}); err != nil {
+		return nil, err
+	}
+
+	recordRFDMerge(existing.ID, commit.Merge, actor)
+
+	log.Printf("RFD %s moved from %s to %s by %s", existing.ID, existing.State, state, actor)
+
+	return _dataStore.GetRFDByID(existing.ID)
+}
+
+// checkStateTransition validates an RFD's change of state against states.transitions.
+// Returns the transition to record, or nil if the state didn't change.
+func checkStateTransition(existing *models.RFD, updated *models.RFD, source *models.ChangeSource) (*models.StateTransition, error) {
+	var from models.RFDState
+	if existing != nil {
+		if existing.State == updated.State {
+			return nil, nil
+		}
+
+		from = existing.State
+	}
+
+	allowed := config.Config.States.AllowedTransitions().Allowed(from, updated.State)
+	if !allowed {
+		if config.Config.States.Enforce {
+			return nil, fmt.Errorf("%w: RFD %s can't move from '%s' to '%s'", ErrIllegalStateTransition, updated.ID, from, updated.State)
+		}
+
+		log.Printf("RFD %s moved from '%s' to '%s' which isn't an allowed transition, flagging it", updated.ID, from, updated.State)
+	}
+
+	transition := &models.StateTransition{
+		RFDID:   updated.ID,
+		From:    from,
+		To:      updated.State,
+		Allowed: allowed,
+	}
+
+	if source != nil {
+		transition.Actor = source.Actor
+		transition.CommitSHA = source.CommitSHA
+	}
+
+	return transition, nil
+}
+
+// recordStateTransition stores a transition found by checkStateTransition,
+// opens a pull request when the RFD enters discussion and merges its branch
+// when it's published or committed
+func recordStateTransition(transition *models.StateTransition) {
+	if transition == nil {
+		return
+	}
+
+	if err := _dataStore.CreateStateTransition(transition); err != nil {
+		log.Printf("Failed to record state transition for RFD %s: %v", transition.RFDID, err)
+	}
+
+	if transition.To == models.Discussion {
+		openRFDPullRequestAsync(transition.RFDID)
+	}
+
+	// ChangeRFDState merges as part of its commit, this catches moves made directly in git.
+	// RFDs seen for the first time are left alone so a first sync doesn't merge old branches.
+	if mergesIntoMain(transition.To) && transition.From != "" {
+		mergeRFDBranchAsync(transition.RFDID, transition.Actor)
+	}
+}
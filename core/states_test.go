@@ -0,0 +1,75 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestChangeRFDState(t *testing.T) {
+	newTestDataStore(t)
+	importTestRFD(t, "0001")
+	remotePath, remote := useTestRemote(t)
+
+	pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+
+	viewer := models.Viewer{LoggedIn: true, Email: "jane@example.com"}
+
+	rfd, err := ChangeRFDState("1", models.Ideation, viewer.Email, viewer)
+	if err != nil {
+		t.Fatalf("Failed to change state: %v", err)
+	}
+
+	if rfd == nil || rfd.State != models.Ideation {
+		t.Errorf("Expected the stored RFD to be in ideation, got %+v", rfd)
+	}
+
+	head := branchHash(t, remote, plumbing.NewBranchReferenceName("0001"))
+
+	content := remoteFile(t, remote, "0001", "rfds/0001/README.md")
+	if !strings.Contains(content, "state: ideation\n") || strings.Contains(content, "state: discussion") {
+		t.Errorf("Expected the state line rewritten on the branch, got %q", content)
+	}
+
+	if !strings.Contains(content, "title: Replication\n") || !strings.HasSuffix(content, "Body.\n") {
+		t.Errorf("Expected the rest of README.md left alone, got %q", content)
+	}
+
+	transitions, err := GetRFDStateTransitions("0001")
+	if err != nil {
+		t.Fatalf("Failed to get state transitions: %v", err)
+	}
+
+	if len(transitions) != 1 || transitions[0].From != models.Discussion || transitions[0].To != models.Ideation ||
+		!transitions[0].Allowed || transitions[0].Actor != viewer.Email || transitions[0].CommitSHA != head.String() {
+		t.Errorf("Expected the move to ideation in the timeline, got %+v", transitions)
+	}
+
+	// Ideation can't go straight to committed
+	config.Config.States.Enforce = true
+
+	if rfd, err := ChangeRFDState("0001", models.Committed, viewer.Email, viewer); !errors.Is(err, ErrIllegalStateTransition) {
+		t.Fatalf("Expected an illegal state transition, got %+v, %v", rfd, err)
+	}
+
+	if got := branchHash(t, remote, plumbing.NewBranchReferenceName("0001")); got != head {
+		t.Errorf("Expected remote 0001 left at %s, got %s", head, got)
+	}
+
+	stored, err := _dataStore.GetRFDByID("0001")
+	if err != nil {
+		t.Fatalf("Failed to get RFD: %v", err)
+	}
+
+	if stored.State != models.Ideation {
+		t.Errorf("Expected the stored RFD left in ideation, got %s", stored.State)
+	}
+
+	if transitions, err := GetRFDStateTransitions("0001"); err != nil || len(transitions) != 1 {
+		t.Errorf("Expected nothing added to the timeline, got %+v, %v", transitions, err)
+	}
+}
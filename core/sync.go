@@ -0,0 +1,455 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/renderer"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var _rfdBranch = regexp.MustCompile(`^\d{4}$`)
+
+var (
+	_syncStatus   models.SyncStatus
+	_syncStatusMu sync.RWMutex
+
+	// Only one sync pass may run at a time
+	_syncRunMu sync.Mutex
+)
+
+// rfdSource is where in the repo the current copy of an RFD lives, its README.md and its attachments
+type rfdSource struct {
+	Branch  string
+	Commit  plumbing.Hash
+	Path    string
+	Content []byte
+	Files   map[string]plumbing.Hash
+}
+
+// StartRepoSync starts the background loop that ingests RFDs straight from the repo
+func StartRepoSync() {
+	interval := config.Config.Sync.IntervalDuration()
+
+	_syncStatusMu.Lock()
+	_syncStatus.Enabled = config.Config.Sync.Enabled
+	_syncStatus.Interval = interval.String()
+	_syncStatusMu.Unlock()
+
+	if !config.Config.Sync.Enabled {
+		log.Println("Repo sync disabled")
+		return
+	}
+
+	log.Printf("Starting repo sync every %s", interval)
+
+	go func() {
+		for {
+			if err := SyncRepo(); err != nil {
+				log.Printf("Repo sync failed: %v", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// GetSyncStatus returns the status of the most recent repo sync
+func GetSyncStatus() models.SyncStatus {
+	_syncStatusMu.RLock()
+	defer _syncStatusMu.RUnlock()
+
+	return _syncStatus
+}
+
+// SyncRepo fetches the RFD repo and updates every RFD whose content changed
+func SyncRepo() error {
+	if !_syncRunMu.TryLock() {
+		return errors.New("sync already running")
+	}
+	defer _syncRunMu.Unlock()
+
+	started := time.Now()
+
+	_syncStatusMu.Lock()
+	_syncStatus.Running = true
+	_syncStatus.LastStartedAt = &started
+	_syncStatusMu.Unlock()
+
+	checked, updated, failed, err := syncRepo()
+
+	finished := time.Now()
+
+	_syncStatusMu.Lock()
+	_syncStatus.Running = false
+	_syncStatus.LastFinishedAt = &finished
+	_syncStatus.Checked = checked
+	_syncStatus.Updated = updated
+	_syncStatus.Failed = failed
+	_syncStatus.LastError = ""
+	if err != nil {
+		_syncStatus.LastError = err.Error()
+	} else {
+		_syncStatus.LastSuccessAt = &finished
+	}
+	_syncStatusMu.Unlock()
+
+	log.Printf("Repo sync finished in %s: %d checked, %d updated, %d failed", finished.Sub(started).Round(time.Millisecond), checked, updated, failed)
+
+	return err
+}
+
+// SyncRFDs re-syncs only the given RFDs, used when we know what changed. Only the main
+// branch and the RFDs' own branches are fetched. removed are RFDs whose README.md was
+// deleted, they're dropped if they're no longer anywhere in the repo.
+// actor is recorded against any revisions this creates.
+func SyncRFDs(rfdNums []string, removed []string, actor string) error {
+	_syncRunMu.Lock()
+	defer _syncRunMu.Unlock()
+
+	sources, err := fetchRFDSourcesFor(rfdNums)
+	if err != nil {
+		return err
+	}
+
+	wasRemoved := map[string]bool{}
+	for _, rfdNum := range removed {
+		wasRemoved[rfdNum] = true
+	}
+
+	var failures []string
+	for _, rfdNum := range rfdNums {
+		source, ok := sources[rfdNum]
+		if !ok && wasRemoved[rfdNum] {
+			log.Printf("RFD %s was deleted from the repo by %s, removing it", rfdNum, actor)
+			if err := deleteRFD(rfdNum); err != nil {
+				log.Printf("Failed to remove RFD %s: %v", rfdNum, err)
+				failures = append(failures, rfdNum)
+			}
+			continue
+		}
+
+		if !ok {
+			log.Printf("RFD %s no longer exists in the repo, skipping", rfdNum)
+			continue
+		}
+
+		if _, err := syncRFDFromSource(rfdNum, source, actor); err != nil {
+			log.Printf("Failed to sync RFD %s: %v", rfdNum, err)
+			failures = append(failures, rfdNum)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to sync RFDs: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+func syncRepo() (checked int, updated int, failed int, err error) {
+	sources, err := fetchRFDSources()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	rfdNums := make([]string, 0, len(sources))
+	for rfdNum := range sources {
+		rfdNums = append(rfdNums, rfdNum)
+	}
+	sort.Strings(rfdNums)
+
+	var failures []string
+	for _, rfdNum := range rfdNums {
+		checked++
+
+		changed, err := syncRFDFromSource(rfdNum, sources[rfdNum], "sync")
+		if err != nil {
+			log.Printf("Failed to sync RFD %s: %v", rfdNum, err)
+			failures = append(failures, rfdNum)
+			failed++
+			continue
+		}
+
+		if changed {
+			updated++
+		}
+	}
+
+	if len(failures) > 0 {
+		return checked, updated, failed, fmt.Errorf("failed to sync RFDs: %s", strings.Join(failures, ", "))
+	}
+
+	return checked, updated, failed, nil
+}
+
+// fetchRFDSources fetches every branch of the repo and finds all RFDs in it
+func fetchRFDSources() (map[string]rfdSource, error) {
+	return fetchRFDSourcesFor(nil)
+}
+
+// fetchRFDSourcesFor fetches only main and the RFDs' branches, nil fetches every branch.
+// Sources for other RFDs are as of when their branches were last fetched.
+func fetchRFDSourcesFor(rfdNums []string) (map[string]rfdSource, error) {
+	var sources map[string]rfdSource
+	err := _repoCache.withBranches(rfdNums, func(r *git.Repository) error {
+		var err error
+		sources, err = collectRFDSources(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// collectRFDSources finds every RFD on the main branch and on NNNN branches.
+// The RFD's own branch wins over main until it has been merged, same as checkoutRFD.
+func collectRFDSources(r *git.Repository) (map[string]rfdSource, error) {
+	sources := map[string]rfdSource{}
+
+	mainCommit, err := remoteBranchCommit(r, config.Config.Repo.MainBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", config.Config.Repo.MainBranch, err)
+	}
+
+	mainTree, err := mainCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	folderTree, err := mainTree.Tree(config.Config.Repo.Folder)
+	if err != nil && err != object.ErrDirectoryNotFound {
+		return nil, err
+	}
+
+	if folderTree != nil {
+		for _, entry := range folderTree.Entries {
+			if entry.Mode.IsFile() || !_rfdBranch.MatchString(entry.Name) {
+				continue
+			}
+
+			path := rfdReadmePath(entry.Name)
+			content, err := readCommitFile(mainCommit, path)
+			if err != nil {
+				continue
+			}
+
+			files, err := rfdFolderFiles(mainCommit, entry.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			sources[entry.Name] = rfdSource{Branch: config.Config.Repo.MainBranch, Commit: mainCommit.Hash, Path: path, Content: content, Files: files}
+		}
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() {
+			return nil
+		}
+
+		branch := strings.TrimPrefix(ref.Name().Short(), "origin/")
+		if !_rfdBranch.MatchString(branch) {
+			return nil
+		}
+
+		commit, err := r.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+
+		path := rfdReadmePath(branch)
+		content, err := readCommitFile(commit, path)
+		if err != nil {
+			return nil
+		}
+
+		// A merged branch is stale, anything since has been committed on main
+		if _, onMain := sources[branch]; onMain {
+			merged := commit.Hash == mainCommit.Hash
+			if !merged {
+				if merged, err = commit.IsAncestor(mainCommit); err != nil {
+					return err
+				}
+			}
+
+			if merged {
+				return nil
+			}
+		}
+
+		files, err := rfdFolderFiles(commit, branch)
+		if err != nil {
+			return err
+		}
+
+		sources[branch] = rfdSource{Branch: branch, Commit: commit.Hash, Path: path, Content: content, Files: files}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+func remoteBranchCommit(r *git.Repository, branch string) (*object.Commit, error) {
+	ref, err := r.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.CommitObject(ref.Hash())
+}
+
+// readCommitFile reads a file as it is at commit
+func readCommitFile(commit *object.Commit, path string) ([]byte, error) {
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(content), nil
+}
+
+func rfdReadmePath(rfdNum string) string {
+	return fmt.Sprintf("%s/%s/README.md", config.Config.Repo.Folder, rfdNum)
+}
+
+// syncRFDFromSource renders the RFD at source and stores it if anything changed
+func syncRFDFromSource(rfdNum string, source rfdSource, actor string) (bool, error) {
+	rendered, err := renderer.RenderRFD(rfdNum, bytes.NewReader(source.Content))
+	if err != nil {
+		return false, fmt.Errorf("failed to render %s@%s: %w", source.Path, source.Branch, err)
+	}
+
+	existing, err := _dataStore.GetRFDByID(rfdNum)
+	if err != nil {
+		return false, err
+	}
+
+	changed := true
+	if existing != nil {
+		// The discussion link may still be on its way into git, don't wipe it out
+		if rendered.Discussion == "" && existing.Discussion != "" {
+			rendered.Discussion = existing.Discussion
+		}
+
+		changed, err = rfdHasChanges(existing, rendered)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if changed {
+		log.Printf("Syncing RFD %s from %s (%s)", rfdNum, source.Branch, source.Commit.String()[:7])
+
+		changeSource := &models.ChangeSource{Actor: actor, CommitSHA: source.Commit.String()}
+		if err := CreateOrUpdateRFD(rendered, config.Config.Sync.SkipDiscussion, changeSource); err != nil {
+			return false, err
+		}
+	} else if existing.Content != rendered.Content {
+		// Same RFD rendered differently, e.g. links to attachments from before they were
+		// served. Not a revision, only the stored HTML is brought up to date.
+		existing.Content = rendered.Content
+		if err := _dataStore.UpdateRFD(existing); err != nil {
+			return false, err
+		}
+	}
+
+	// Relations aren't part of the stored RFD, so a change to only them is picked up here
+	relationsChanged := false
+	if !changed {
+		relationsChanged, err = syncRFDRelations(rendered)
+		if err != nil {
+			return false, fmt.Errorf("failed to sync relations: %w", err)
+		}
+	}
+
+	filesChanged, err := syncRFDFiles(rfdNum, source.Files)
+	if err != nil {
+		return changed, fmt.Errorf("failed to sync files: %w", err)
+	}
+
+	return changed || relationsChanged || filesChanged, nil
+}
+
+// rfdHasChanges compares a stored RFD against a freshly rendered one
+func rfdHasChanges(existing *models.RFD, rendered *models.RFD) (bool, error) {
+	if existing.Title != rendered.Title ||
+		existing.State != rendered.State ||
+		existing.Discussion != rendered.Discussion ||
+		existing.Public != rendered.Public ||
+		existing.ContentMD != rendered.ContentMD {
+		return true, nil
+	}
+
+	if strings.Join(existing.Tags, ",") != strings.Join(normalizeTags(rendered.Tags), ",") {
+		return true, nil
+	}
+
+	if strings.Join(existing.Visibility, ",") != strings.Join(normalizeVisibility(rendered.Visibility), ",") {
+		return true, nil
+	}
+
+	existingAuthors := []string{}
+	for _, author := range existing.Authors {
+		existingAuthors = append(existingAuthors, author.ID)
+	}
+
+	// Resolved the way processRFDAuthors will, so "Jane Doe" matches an author stored with her email
+	renderedAuthors := []string{}
+	seen := map[string]bool{}
+	for _, authorStr := range rendered.AuthorStrings {
+		for _, singleAuthor := range strings.Split(authorStr, ",") {
+			singleAuthor = strings.TrimSpace(singleAuthor)
+			if singleAuthor == "" {
+				continue
+			}
+
+			author, err := findAuthor(models.ParseAuthor(singleAuthor))
+			if err != nil {
+				return false, err
+			}
+
+			// Saving it would create a new author
+			if author == nil {
+				return true, nil
+			}
+
+			if !seen[author.ID] {
+				seen[author.ID] = true
+				renderedAuthors = append(renderedAuthors, author.ID)
+			}
+		}
+	}
+
+	sort.Strings(existingAuthors)
+	sort.Strings(renderedAuthors)
+
+	return strings.Join(existingAuthors, ",") != strings.Join(renderedAuthors, ","), nil
+}
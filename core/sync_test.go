@@ -0,0 +1,126 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestSyncMatchesNameOnlyAuthorsWithEmail(t *testing.T) {
+	newTestDataStore(t)
+
+	// Jane's email was picked up from another RFD
+	if err := _dataStore.CreateAuthor(&models.Author{Name: "Jane Doe", Email: "jane@example.com"}); err != nil {
+		t.Fatalf("Failed to create author: %v", err)
+	}
+
+	source := rfdSource{
+		Branch:  "0001",
+		Commit:  plumbing.NewHash("1111111111111111111111111111111111111111"),
+		Path:    "rfds/0001/README.md",
+		Content: []byte("---\ntitle: Replication\nauthors:\n  - Jane Doe\nstate: discussion\n---\n\nBody.\n"),
+	}
+
+	changed, err := syncRFDFromSource("0001", source, "")
+	if err != nil || !changed {
+		t.Fatalf("Expected the first sync to store the RFD, got %v, %v", changed, err)
+	}
+
+	changed, err = syncRFDFromSource("0001", source, "")
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if changed {
+		t.Error("Expected the second sync to find no changes")
+	}
+
+	revisions, err := GetRFDRevisions("0001")
+	if err != nil {
+		t.Fatalf("Failed to get revisions: %v", err)
+	}
+
+	if len(revisions) != 1 {
+		t.Errorf("Expected a single revision, got %d", len(revisions))
+	}
+}
+
+func TestSyncRFDsFetchesOnlyTheirBranches(t *testing.T) {
+	newTestDataStore(t)
+	remotePath, _ := useTestRemote(t)
+
+	// Clone the cache before anything else is pushed
+	if _, err := fetchRFDSources(); err != nil {
+		t.Fatalf("Failed to fetch: %v", err)
+	}
+
+	pushTestFile(t, remotePath, "main", "rfds/0002/README.md", testRFDFile("Search", models.Discussion))
+	pushTestFile(t, remotePath, "0001", "rfds/0001/README.md", testRFDFile("Replication", models.Discussion))
+
+	if err := SyncRFDs([]string{"0002"}, nil, "jane@example.com"); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if rfd, err := _dataStore.GetRFDByID("0002"); err != nil || rfd == nil || rfd.Title != "Search" {
+		t.Errorf("Expected 0002 to be synced from main, got %+v, %v", rfd, err)
+	}
+
+	// 0001's branch wasn't part of the push, so it's left as it was
+	sources, err := fetchRFDSourcesFor([]string{})
+	if err != nil {
+		t.Fatalf("Failed to fetch: %v", err)
+	}
+
+	if source, ok := sources["0001"]; !ok || string(source.Content) != "rfd" {
+		t.Errorf("Expected 0001's branch not to be fetched, got %+v", source)
+	}
+}
+
+func TestSyncRFDsRemovesDeletedRFDs(t *testing.T) {
+	newTestDataStore(t)
+	remotePath, _ := useTestRemote(t)
+
+	pushTestFile(t, remotePath, "main", "rfds/0002/README.md", testRFDFile("Search", models.Discussion))
+
+	for _, rfd := range []models.RFD{
+		{ID: "0002", RFDMeta: models.RFDMeta{Title: "Search", State: models.Discussion}},
+		{ID: "0003", RFDMeta: models.RFDMeta{Title: "Deleted", Tags: []string{"infra"}}},
+		{ID: "0004", RFDMeta: models.RFDMeta{Title: "Only in the database"}},
+	} {
+		if err := _dataStore.ImportRFD(&rfd); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	if err := _dataStore.CreateTag(&models.Tag{Name: "infra", RFDs: []string{"0003", "0004"}}); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+
+	// 0002's README.md was deleted from its branch but it's still on main
+	if err := SyncRFDs([]string{"0002", "0003", "0004"}, []string{"0002", "0003"}, "jane@example.com"); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if rfd, err := _dataStore.GetRFDByID("0002"); err != nil || rfd == nil {
+		t.Errorf("Expected 0002 to be kept, got %+v, %v", rfd, err)
+	}
+
+	if rfd, err := _dataStore.GetRFDByID("0003"); err != nil || rfd != nil {
+		t.Errorf("Expected 0003 to be removed, got %+v, %v", rfd, err)
+	}
+
+	// Touched by the push but not deleted by it
+	if rfd, err := _dataStore.GetRFDByID("0004"); err != nil || rfd == nil {
+		t.Errorf("Expected 0004 to be kept, got %+v, %v", rfd, err)
+	}
+
+	tag, err := _dataStore.GetTag("infra")
+	if err != nil {
+		t.Fatalf("Failed to get tag: %v", err)
+	}
+
+	if len(tag.RFDs) != 1 || tag.RFDs[0] != "0004" {
+		t.Errorf("Expected 0003 to be taken out of its tags, got %v", tag.RFDs)
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/adrg/frontmatter"
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	// rfdTemplatesFolder holds the templates new RFDs can be created from, one NAME.md per template
+	rfdTemplatesFolder = "templates"
+
+	// defaultTemplatePath is the template at the repo root, the only one before there could be several
+	defaultTemplatePath = "TEMPLATE.md"
+	defaultTemplateName = "default"
+)
+
+// ErrTemplateNotFound is returned when creating an RFD from a template the repo doesn't have
+var ErrTemplateNotFound = errors.New("template not found")
+
+// GetRFDTemplates lists the templates on the main branch, TEMPLATE.md first if there is one
+func GetRFDTemplates() ([]models.RFDTemplate, error) {
+	var templates []models.RFDTemplate
+	err := _repoCache.withRepo(func(r *git.Repository) error {
+		mainCommit, err := remoteBranchCommit(r, config.Config.Repo.MainBranch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", config.Config.Repo.MainBranch, err)
+		}
+
+		templates, err = readRFDTemplates(mainCommit)
+		return err
+	})
+
+	return templates, err
+}
+
+// readRFDTemplates finds TEMPLATE.md and every templates/*.md in commit.
+// Templates with broken frontmatter are skipped so one bad file doesn't block creating RFDs.
+func readRFDTemplates(commit *object.Commit) ([]models.RFDTemplate, error) {
+	paths := []string{}
+
+	if _, err := commit.File(defaultTemplatePath); err == nil {
+		paths = append(paths, defaultTemplatePath)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	folderTree, err := tree.Tree(rfdTemplatesFolder)
+	if err != nil && err != object.ErrDirectoryNotFound {
+		return nil, err
+	}
+
+	if folderTree != nil {
+		names := []string{}
+		for _, entry := range folderTree.Entries {
+			if entry.Mode.IsFile() && strings.HasSuffix(entry.Name, ".md") {
+				names = append(names, entry.Name)
+			}
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			paths = append(paths, path.Join(rfdTemplatesFolder, name))
+		}
+	}
+
+	templates := []models.RFDTemplate{}
+	for _, templatePath := range paths {
+		content, err := readCommitFile(commit, templatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		template, _, err := parseRFDTemplate(templatePath, content)
+		if err != nil {
+			log.Printf("Skipping template %s: %v", templatePath, err)
+			continue
+		}
+
+		templates = append(templates, *template)
+	}
+
+	return templates, nil
+}
+
+// parseRFDTemplate reads a template's defaults off its frontmatter and returns its body
+func parseRFDTemplate(templatePath string, content []byte) (*models.RFDTemplate, []byte, error) {
+	var rfdMeta models.RFDMetaYAML
+	body, err := frontmatter.Parse(bytes.NewReader(content), &rfdMeta)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+	}
+
+	name := defaultTemplateName
+	if templatePath != defaultTemplatePath {
+		name = strings.TrimSuffix(path.Base(templatePath), ".md")
+	}
+
+	state := models.Ideation
+	if rfdMeta.State != "" {
+		if !rfdMeta.State.Valid() {
+			return nil, nil, fmt.Errorf("invalid state %q", rfdMeta.State)
+		}
+		state = rfdMeta.State
+	}
+
+	tags := rfdMeta.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &models.RFDTemplate{
+		Name:   name,
+		Path:   templatePath,
+		State:  state,
+		Tags:   tags,
+		Public: rfdMeta.Public,
+	}, body, nil
+}
+
+// findRFDTemplate picks the template named name, or the first one when name is empty
+func findRFDTemplate(templates []models.RFDTemplate, name string) (*models.RFDTemplate, error) {
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("%w: the repo has no %s or %s/*.md", ErrTemplateNotFound, defaultTemplatePath, rfdTemplatesFolder)
+	}
+
+	if name == "" {
+		return &templates[0], nil
+	}
+
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+}
+
+// mergeTags adds extra to the template's tags, skipping ones it already has
+func mergeTags(tags []string, extra []string) []string {
+	merged := append([]string{}, tags...)
+	for _, tag := range extra {
+		found := false
+		for _, existing := range merged {
+			if strings.EqualFold(existing, tag) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, tag)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestReadRFDTemplates(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+
+	worktree, _ := r.Worktree()
+	commitTestFile(t, worktree, "TEMPLATE.md", "---\ntitle: Template\n---\n# Summary\n")
+	commitTestFile(t, worktree, "templates/rfd.md", "---\ntitle: RFD\n---\n# RFD\n")
+	commitTestFile(t, worktree, "templates/notes.txt", "not a template")
+	commitTestFile(t, worktree, "templates/broken.md", "---\nstate: [\n---\n")
+	hash := commitTestFile(t, worktree, "templates/adr.md", "---\ntitle: ADR\nstate: discussion\ntags: [adr]\npublic: true\n---\n# Decision\n")
+
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("Failed to load commit: %v", err)
+	}
+
+	templates, err := readRFDTemplates(commit)
+	if err != nil {
+		t.Fatalf("Failed to read templates: %v", err)
+	}
+
+	want := []models.RFDTemplate{
+		{Name: "default", Path: "TEMPLATE.md", State: models.Ideation, Tags: []string{}},
+		{Name: "adr", Path: "templates/adr.md", State: models.Discussion, Tags: []string{"adr"}, Public: true},
+		{Name: "rfd", Path: "templates/rfd.md", State: models.Ideation, Tags: []string{}},
+	}
+
+	if !reflect.DeepEqual(templates, want) {
+		t.Errorf("Expected %+v, got %+v", want, templates)
+	}
+
+	if template, err := findRFDTemplate(templates, ""); err != nil || template.Name != "default" {
+		t.Errorf("Expected the default template when none is picked, got %v, %v", template, err)
+	}
+
+	if template, err := findRFDTemplate(templates, "adr"); err != nil || template.Path != "templates/adr.md" {
+		t.Errorf("Expected the adr template, got %v, %v", template, err)
+	}
+
+	if _, err := findRFDTemplate(templates, "postmortem"); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	got := mergeTags([]string{"adr", "infra"}, []string{"Infra", "security"})
+	want := []string{"adr", "infra", "security"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CanViewRFD reports whether viewer may see the RFD, false if it doesn't exist
+func CanViewRFD(id string, viewer models.Viewer) (bool, error) {
+	if id != "latest" {
+		if id != "" && !_validId.Match([]byte(id)) {
+			return false, nil
+		}
+
+		if len(id) < 4 {
+			id = fmt.Sprintf("%04s", id)
+		}
+	}
+
+	rfd, err := GetRFDByID(id, viewer)
+	if err != nil {
+		return false, err
+	}
+
+	if rfd == nil {
+		return false, nil
+	}
+
+	return rfd.VisibleTo(viewer), nil
+}
+
+// CanUpdateRFD reports whether viewer may overwrite the RFD, anyone may create one that doesn't exist yet
+func CanUpdateRFD(id string, viewer models.Viewer) (bool, error) {
+	if id != "" && !_validId.Match([]byte(id)) {
+		return false, nil
+	}
+
+	if len(id) < 4 {
+		id = fmt.Sprintf("%04s", id)
+	}
+
+	rfd, err := _dataStore.GetRFDByID(id)
+	if err != nil {
+		return false, err
+	}
+
+	return rfd == nil || rfd.VisibleTo(viewer), nil
+}
+
+// visibleRFDs drops the RFDs viewer isn't allowed to see
+func visibleRFDs(rfds []models.RFD, viewer models.Viewer) []models.RFD {
+	visible := make([]models.RFD, 0, len(rfds))
+	for _, rfd := range rfds {
+		if rfd.VisibleTo(viewer) {
+			visible = append(visible, rfd)
+		}
+	}
+
+	return visible
+}
+
+// normalizeVisibility trims and sorts the groups an RFD is restricted to, dropping blanks and duplicates
+func normalizeVisibility(groups []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(groups))
+
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		key := strings.ToLower(group)
+		if group != "" && !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, group)
+		}
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestRestrictedRFDWritesNeedVisibility(t *testing.T) {
+	newTestDataStore(t)
+
+	restricted := &models.RFD{ID: "0001", RFDMeta: models.RFDMeta{
+		Title:      "Incident review",
+		State:      models.Discussion,
+		Visibility: []string{"security-team"},
+	}}
+	if err := _dataStore.ImportRFD(restricted); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	outsider := models.Viewer{LoggedIn: true, Email: "outsider@example.com", Groups: []string{"engineering"}}
+	member := models.Viewer{LoggedIn: true, Email: "member@example.com", Groups: []string{"security-team"}}
+
+	// Overwriting with POST /api/v1/rfds/:id
+	if ok, err := CanUpdateRFD("1", outsider); err != nil || ok {
+		t.Errorf("Expected an outsider not to be able to update the RFD, got %v, %v", ok, err)
+	}
+
+	if ok, err := CanUpdateRFD("1", member); err != nil || !ok {
+		t.Errorf("Expected a member to be able to update the RFD, got %v, %v", ok, err)
+	}
+
+	if ok, err := CanUpdateRFD("0002", outsider); err != nil || !ok {
+		t.Errorf("Expected anyone to be able to create a new RFD, got %v, %v", ok, err)
+	}
+
+	// Changing the state, found before anything is committed
+	if rfd, err := ChangeRFDState("1", models.Published, outsider.Email, outsider); err != nil || rfd != nil {
+		t.Errorf("Expected the RFD not to be found changing its state, got %v, %v", rfd, err)
+	}
+
+	// Uploading a file
+	if file, err := SaveRFDFile("1", "diagram.png", []byte("png"), outsider.Email, outsider); err != nil || file != nil {
+		t.Errorf("Expected the RFD not to be found uploading a file, got %v, %v", file, err)
+	}
+
+	files, err := _dataStore.GetRFDFiles("0001")
+	if err != nil {
+		t.Fatalf("Failed to get files: %v", err)
+	}
+
+	if len(files) != 0 {
+		t.Errorf("Expected no files to be stored, got %v", files)
+	}
+}
+
+func TestGetTagsHidesRestrictedRFDs(t *testing.T) {
+	newTestDataStore(t)
+
+	rfds := []models.RFD{
+		{ID: "0001", RFDMeta: models.RFDMeta{Title: "Open"}},
+		{ID: "0002", RFDMeta: models.RFDMeta{Title: "Incident review", Visibility: []string{"security-team"}}},
+	}
+
+	for i := range rfds {
+		if err := _dataStore.ImportRFD(&rfds[i]); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	tags := []models.Tag{
+		{Name: "infra", RFDs: []string{"0001", "0002"}},
+		{Name: "incidents", RFDs: []string{"0002"}},
+	}
+
+	for i := range tags {
+		if err := _dataStore.CreateTag(&tags[i]); err != nil {
+			t.Fatalf("Failed to create tag: %v", err)
+		}
+	}
+
+	outsider := models.Viewer{LoggedIn: true, Email: "outsider@example.com"}
+	visible, err := GetTags(outsider)
+	if err != nil {
+		t.Fatalf("Failed to get tags: %v", err)
+	}
+
+	if len(visible) != 1 || visible[0].Name != "infra" || len(visible[0].RFDs) != 1 || visible[0].RFDs[0] != "0001" {
+		t.Errorf("Expected only infra with 0001, got %v", visible)
+	}
+
+	member := models.Viewer{LoggedIn: true, Email: "member@example.com", Groups: []string{"security-team"}}
+	visible, err = GetTags(member)
+	if err != nil {
+		t.Fatalf("Failed to get tags: %v", err)
+	}
+
+	if len(visible) != 2 {
+		t.Errorf("Expected both tags for a member, got %v", visible)
+	}
+}
+
+func TestGetRFDsByTagSkipsMissingRFDs(t *testing.T) {
+	newTestDataStore(t)
+
+	rfd := &models.RFD{ID: "0001", RFDMeta: models.RFDMeta{Title: "Open"}}
+	if err := _dataStore.ImportRFD(rfd); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	// 0002 was deleted, but the tag still lists it
+	if err := _dataStore.CreateTag(&models.Tag{Name: "infra", RFDs: []string{"0001", "0002"}}); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+
+	rfds, err := GetRFDsByTag("infra", models.Viewer{LoggedIn: true})
+	if err != nil {
+		t.Fatalf("Failed to get RFDs by tag: %v", err)
+	}
+
+	if len(rfds) != 1 || rfds[0].ID != "0001" {
+		t.Errorf("Expected only 0001, got %v", rfds)
+	}
+}
+
+func TestSearchRFDsFillsPageWithVisibleResults(t *testing.T) {
+	newTestDataStore(t)
+
+	// More restricted matches than fit on a page, all ranked above the visible ones
+	for i := 1; i <= searchLimit+10; i++ {
+		rfd := &models.RFD{ID: fmt.Sprintf("%04d", i), RFDMeta: models.RFDMeta{
+			Title:      "Replication incident",
+			Visibility: []string{"security-team"},
+		}, ContentMD: "Replication broke."}
+		if err := _dataStore.ImportRFD(rfd); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	for i := 1; i <= 3; i++ {
+		rfd := &models.RFD{ID: fmt.Sprintf("%04d", 100+i), RFDMeta: models.RFDMeta{Title: "Databases"}, ContentMD: "Notes on replication."}
+		if err := _dataStore.ImportRFD(rfd); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	results, err := SearchRFDs("replication", models.Viewer{LoggedIn: true, Email: "outsider@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf("Expected the 3 visible results, got %d", len(results))
+	}
+
+	results, err = SearchRFDs("replication", models.Viewer{LoggedIn: true, Groups: []string{"security-team"}})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	if len(results) != searchLimit {
+		t.Errorf("Expected a full page of %d results for a member, got %d", searchLimit, len(results))
+	}
+}
+
+func TestCanViewLatestRFD(t *testing.T) {
+	newTestDataStore(t)
+
+	rfds := []models.RFD{
+		{ID: "0001", RFDMeta: models.RFDMeta{Title: "Open"}},
+		{ID: "0002", RFDMeta: models.RFDMeta{Title: "Incident review", Visibility: []string{"security-team"}}},
+	}
+
+	for i := range rfds {
+		if err := _dataStore.ImportRFD(&rfds[i]); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	outsider := models.Viewer{LoggedIn: true, Email: "outsider@example.com"}
+	if ok, err := CanViewRFD("latest", outsider); err != nil || !ok {
+		t.Errorf("Expected an outsider to be able to view the latest RFD, got %v, %v", ok, err)
+	}
+
+	// The newest RFD is restricted, so the latest one the outsider can see is 0001
+	rfd, err := GetRFDByID("latest", outsider)
+	if err != nil || rfd == nil || rfd.ID != "0001" {
+		t.Errorf("Expected 0001 as the latest RFD for an outsider, got %v, %v", rfd, err)
+	}
+
+	member := models.Viewer{LoggedIn: true, Email: "member@example.com", Groups: []string{"security-team"}}
+	if ok, err := CanViewRFD("latest", member); err != nil || !ok {
+		t.Errorf("Expected a member to be able to view the latest RFD, got %v, %v", ok, err)
+	}
+
+	rfd, err = GetRFDByID("latest", member)
+	if err != nil || rfd == nil || rfd.ID != "0002" {
+		t.Errorf("Expected 0002 as the latest RFD for a member, got %v, %v", rfd, err)
+	}
+
+	// Signed out viewers only get public RFDs, and there are none
+	if ok, err := CanViewRFD("latest", models.Viewer{}); err != nil || ok {
+		t.Errorf("Expected no latest RFD for a signed out viewer, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,211 @@
+// Package diff computes word level differences between two versions of an RFD's markdown
+package diff
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// OpType is what happened to a run of text
+type OpType int
+
+const (
+	Equal OpType = iota
+	Insert
+	Delete
+)
+
+// Op is a run of text that was kept, inserted or deleted
+type Op struct {
+	Type OpType
+	Text string
+}
+
+// maxCells caps the size of the LCS table, larger hunks are shown as a plain replacement
+const maxCells = 4_000_000
+
+var wordToken = regexp.MustCompile(`[\p{L}\p{N}_]+|\s+|.`)
+
+// Words diffs two texts line by line, then word by word within lines that changed
+func Words(oldText, newText string) []Op {
+	var ops []Op
+
+	lineOps := diffTokens(splitLines(oldText), splitLines(newText))
+
+	for i := 0; i < len(lineOps); i++ {
+		op := lineOps[i]
+		if op.Type != Delete || i+1 >= len(lineOps) || lineOps[i+1].Type != Insert {
+			ops = append(ops, op)
+			continue
+		}
+
+		// A block of lines was replaced, find the words that actually changed
+		inserted := lineOps[i+1]
+		ops = append(ops, diffTokens(splitWords(op.Text), splitWords(inserted.Text))...)
+		i++
+	}
+
+	return merge(ops)
+}
+
+// HasChanges reports whether ops contain any insertions or deletions
+func HasChanges(ops []Op) bool {
+	for _, op := range ops {
+		if op.Type != Equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HTML renders ops inline with <ins> and <del>, meant to go inside a <pre>
+func HTML(ops []Op) template.HTML {
+	var b strings.Builder
+
+	for _, op := range ops {
+		text := html.EscapeString(op.Text)
+
+		switch op.Type {
+		case Insert:
+			b.WriteString("<ins>" + text + "</ins>")
+		case Delete:
+			b.WriteString("<del>" + text + "</del>")
+		default:
+			b.WriteString(text)
+		}
+	}
+
+	return template.HTML(b.String())
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.SplitAfter(text, "\n")
+}
+
+func splitWords(text string) []string {
+	return wordToken.FindAllString(text, -1)
+}
+
+// diffTokens diffs two token lists using the longest common subsequence
+func diffTokens(a, b []string) []Op {
+	// Trim what's common at either end, it's usually most of the document
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := []Op{}
+	if prefix > 0 {
+		ops = append(ops, Op{Type: Equal, Text: strings.Join(a[:prefix], "")})
+	}
+
+	ops = append(ops, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	if suffix > 0 {
+		ops = append(ops, Op{Type: Equal, Text: strings.Join(a[len(a)-suffix:], "")})
+	}
+
+	return merge(ops)
+}
+
+func lcs(a, b []string) []Op {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxCells {
+		return replacement(a, b)
+	}
+
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	cols := len(b) + 1
+	lengths := make([]int32, (len(a)+1)*cols)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i*cols+j] = lengths[(i+1)*cols+j+1] + 1
+			} else if lengths[(i+1)*cols+j] >= lengths[i*cols+j+1] {
+				lengths[i*cols+j] = lengths[(i+1)*cols+j]
+			} else {
+				lengths[i*cols+j] = lengths[i*cols+j+1]
+			}
+		}
+	}
+
+	ops := []Op{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Type: Equal, Text: a[i]})
+			i++
+			j++
+		case lengths[(i+1)*cols+j] >= lengths[i*cols+j+1]:
+			ops = append(ops, Op{Type: Delete, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Type: Insert, Text: b[j]})
+			j++
+		}
+	}
+
+	ops = append(ops, replacement(a[i:], b[j:])...)
+
+	return ops
+}
+
+func replacement(a, b []string) []Op {
+	ops := []Op{}
+	if len(a) > 0 {
+		ops = append(ops, Op{Type: Delete, Text: strings.Join(a, "")})
+	}
+	if len(b) > 0 {
+		ops = append(ops, Op{Type: Insert, Text: strings.Join(b, "")})
+	}
+
+	return ops
+}
+
+// merge joins neighbouring ops of the same type and puts deletes before inserts
+func merge(ops []Op) []Op {
+	merged := []Op{}
+
+	for _, op := range ops {
+		if op.Text == "" {
+			continue
+		}
+
+		n := len(merged)
+		if n > 0 && merged[n-1].Type == op.Type {
+			merged[n-1].Text += op.Text
+			continue
+		}
+
+		// Keep delete/insert pairs in a consistent order so replacements read naturally
+		if n > 1 && op.Type == Delete && merged[n-1].Type == Insert && merged[n-2].Type == Delete {
+			merged[n-2].Text += op.Text
+			continue
+		}
+
+		if n > 0 && op.Type == Delete && merged[n-1].Type == Insert {
+			merged = append(merged[:n-1], op, merged[n-1])
+			continue
+		}
+
+		merged = append(merged, op)
+	}
+
+	return merged
+}
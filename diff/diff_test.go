@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordsFindsChangedWords(t *testing.T) {
+	oldText := "# Title\n\nWe will use postgres for storage.\n\nUnchanged paragraph.\n"
+	newText := "# Title\n\nWe will use sqlite for storage.\n\nUnchanged paragraph.\n"
+
+	ops := Words(oldText, newText)
+
+	expected := []Op{
+		{Type: Equal, Text: "# Title\n\nWe will use "},
+		{Type: Delete, Text: "postgres"},
+		{Type: Insert, Text: "sqlite"},
+		{Type: Equal, Text: " for storage.\n\nUnchanged paragraph.\n"},
+	}
+
+	if len(ops) != len(expected) {
+		t.Fatalf("Expected %d ops, got %d: %+v", len(expected), len(ops), ops)
+	}
+
+	for i := range expected {
+		if ops[i] != expected[i] {
+			t.Errorf("Op %d: expected %+v, got %+v", i, expected[i], ops[i])
+		}
+	}
+}
+
+func TestWordsRebuildsBothSides(t *testing.T) {
+	oldText := "one two three\nfour five\nsix\n"
+	newText := "zero one three\nfour five six\nseven\n"
+
+	ops := Words(oldText, newText)
+
+	var oldBuilt, newBuilt strings.Builder
+	for _, op := range ops {
+		if op.Type != Insert {
+			oldBuilt.WriteString(op.Text)
+		}
+		if op.Type != Delete {
+			newBuilt.WriteString(op.Text)
+		}
+	}
+
+	if oldBuilt.String() != oldText {
+		t.Errorf("Old side doesn't round trip: %q", oldBuilt.String())
+	}
+
+	if newBuilt.String() != newText {
+		t.Errorf("New side doesn't round trip: %q", newBuilt.String())
+	}
+
+	if !HasChanges(ops) {
+		t.Error("Expected changes")
+	}
+
+	if HasChanges(Words(oldText, oldText)) {
+		t.Error("Expected no changes for identical text")
+	}
+}
+
+func TestHTMLEscapes(t *testing.T) {
+	ops := []Op{
+		{Type: Equal, Text: "a "},
+		{Type: Delete, Text: "<b>"},
+		{Type: Insert, Text: "&"},
+	}
+
+	expected := "a <del>&lt;b&gt;</del><ins>&amp;</ins>"
+	if got := string(HTML(ops)); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,80 @@
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiClient makes JSON requests against a forge's REST API
+type apiClient struct {
+	baseURL    string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func newAPIClient(baseURL string, headers map[string]string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		headers: headers,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (c *apiClient) do(method string, path string, body []byte, result interface{}) error {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode, Message: apiErrorMessage(respBody)}
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+// apiErrorMessage pulls the message out of an error response.
+// GitLab sometimes sends a list or object of messages instead of a string.
+func apiErrorMessage(body []byte) string {
+	var apiErr struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	json.Unmarshal(body, &apiErr)
+
+	var message string
+	if err := json.Unmarshal(apiErr.Message, &message); err == nil {
+		return message
+	}
+
+	if len(apiErr.Message) > 0 {
+		return string(apiErr.Message)
+	}
+
+	return apiErr.Error
+}
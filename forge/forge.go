@@ -0,0 +1,219 @@
+// Package forge talks to the service hosting the RFD repo, such as GitHub
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// Forge is the service hosting the RFD repo. It knows how to reach the repo
+// with git, how to link to it on the web and how to open pull requests.
+type Forge interface {
+	// CloneURL is the URL git clones and pushes to over protocol
+	CloneURL(protocol Protocol) string
+
+	// Auth returns what git should authenticate with over protocol, nil for none
+	Auth(protocol Protocol, creds Credentials) (transport.AuthMethod, error)
+
+	// FileURL links to a file on a branch, empty if the forge has no web UI
+	FileURL(branch string, path string) string
+
+	// BranchURL links to a branch, empty if the forge has no web UI
+	BranchURL(branch string) string
+
+	// EditURL links to editing a file on a branch in the forge's online editor, empty if it has none
+	EditURL(branch string, path string) string
+
+	// OpenPullRequest opens a pull request, or returns the open one if the head branch already has one
+	OpenPullRequest(pr PullRequest) (*PullRequestResult, error)
+}
+
+// Forge types accepted by New
+const (
+	TypeGithub = "github"
+	TypeGitlab = "gitlab"
+	TypeGitea  = "gitea"
+	TypeGit    = "git" // Any git remote, no web links or pull requests
+)
+
+// Protocol is how git talks to the forge
+type Protocol string
+
+const (
+	ProtocolSSH   Protocol = "ssh"
+	ProtocolHTTPS Protocol = "https"
+)
+
+// Credentials for reaching the repo with git. Only the ones for the protocol in use are needed.
+type Credentials struct {
+	SSHUsername   string // Defaults to git
+	SSHPrivateKey string // PEM encoded deploy key
+
+	Username string // HTTPS username, defaults to whatever the forge expects alongside a token
+	Token    string // HTTPS token or password
+}
+
+// ErrNotSupported is returned for things the forge can't do, such as opening pull requests on a plain git remote
+var ErrNotSupported = errors.New("not supported by this forge")
+
+// Options configures New
+type Options struct {
+	Type    string // One of the Type constants, detected from RepoURL when empty
+	RepoURL string // Web URL of the repo, e.g. https://github.com/owner/repo, or the clone URL for TypeGit
+	APIURL  string // Defaults to the forge's public API, or the one on RepoURL's host for self hosted forges
+	Token   string // API token, only needed to open pull requests
+}
+
+// New creates the forge for opts.Type
+func New(opts Options) (Forge, error) {
+	forgeType := opts.Type
+	if forgeType == "" {
+		forgeType = DetectType(opts.RepoURL)
+	}
+
+	switch forgeType {
+	case TypeGithub:
+		return NewGithub(opts.APIURL, opts.Token, opts.RepoURL)
+	case TypeGitlab:
+		return NewGitlab(opts.APIURL, opts.Token, opts.RepoURL)
+	case TypeGitea:
+		return NewGitea(opts.APIURL, opts.Token, opts.RepoURL)
+	case TypeGit:
+		return NewGit(opts.RepoURL), nil
+	default:
+		return nil, fmt.Errorf("unknown forge type: %s (valid options: github, gitlab, gitea, git)", forgeType)
+	}
+}
+
+// DetectType guesses the forge type from the repo URL. Anything that isn't
+// a local repo or gitlab.com is assumed to be GitHub, same as before forges were configurable.
+func DetectType(repoURL string) string {
+	if isLocalURL(repoURL) {
+		return TypeGit
+	}
+
+	u, err := url.Parse(repoURL)
+	if err == nil && u.Hostname() == "gitlab.com" {
+		return TypeGitlab
+	}
+
+	return TypeGithub
+}
+
+// isLocalURL reports whether repoURL is a file:// URL or a path on disk
+func isLocalURL(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "file://") || strings.HasPrefix(repoURL, "/") || strings.HasPrefix(repoURL, ".")
+}
+
+// webRepo is a repo on a forge with a web UI, shared by the forge implementations
+type webRepo struct {
+	url  string // https://host/owner/repo
+	base string // https://host
+	host string
+	path string // owner/repo, may have more parts on forges with nested groups
+}
+
+func parseWebRepo(repoURL string) (webRepo, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return webRepo{}, err
+	}
+
+	path := strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/")
+	if u.Host == "" || !strings.Contains(path, "/") {
+		return webRepo{}, errors.New("repo url must look like https://host/owner/repo")
+	}
+
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	base := fmt.Sprintf("%s://%s", scheme, u.Host)
+
+	return webRepo{
+		url:  base + "/" + path,
+		base: base,
+		host: u.Host,
+		path: path,
+	}, nil
+}
+
+// ownerAndRepo splits the path into owner and repo, for forges without nested groups
+func (w webRepo) ownerAndRepo() (string, string, error) {
+	parts := strings.Split(w.path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("repo url must look like https://host/owner/repo")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func (w webRepo) cloneURL(protocol Protocol) string {
+	if protocol == ProtocolSSH {
+		return fmt.Sprintf("%s:%s.git", strings.Split(w.host, ":")[0], w.path)
+	}
+
+	return w.url + ".git"
+}
+
+// auth builds the auth method for protocol, tokenUsername is used when creds has no username
+func auth(protocol Protocol, creds Credentials, tokenUsername string) (transport.AuthMethod, error) {
+	switch protocol {
+	case ProtocolSSH:
+		username := creds.SSHUsername
+		if username == "" {
+			username = "git"
+		}
+
+		keys, err := ssh.NewPublicKeys(username, []byte(creds.SSHPrivateKey), "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %w", err)
+		}
+
+		return keys, nil
+	case ProtocolHTTPS:
+		if creds.Token == "" {
+			return nil, nil
+		}
+
+		username := creds.Username
+		if username == "" {
+			username = tokenUsername
+		}
+
+		return &githttp.BasicAuth{Username: username, Password: creds.Token}, nil
+	default:
+		return nil, fmt.Errorf("unknown protocol: %s", protocol)
+	}
+}
+
+// PullRequest is a request to merge Head into Base
+type PullRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+}
+
+// PullRequestResult is an opened pull request
+type PullRequestResult struct {
+	Number int
+	URL    string
+}
+
+// APIError is a failed call to a forge's API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("forge api returned %d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,163 @@
+package forge
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestDetectType(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/acme/rfds":        TypeGithub,
+		"https://github.example.com/acme/rfd": TypeGithub,
+		"https://gitlab.com/acme/team/rfds":   TypeGitlab,
+		"file:///srv/git/rfds.git":            TypeGit,
+		"/srv/git/rfds.git":                   TypeGit,
+	}
+
+	for repoURL, want := range tests {
+		if got := DetectType(repoURL); got != want {
+			t.Errorf("DetectType(%q) = %q, want %q", repoURL, got, want)
+		}
+	}
+}
+
+func TestNewUnknownType(t *testing.T) {
+	if _, err := New(Options{Type: "svn", RepoURL: "https://example.com/acme/rfds"}); err == nil {
+		t.Error("Expected an error for an unknown forge type")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		forgeType string
+		repoURL   string
+		ssh       string
+		https     string
+		file      string
+		branch    string
+		edit      string
+	}{
+		{
+			forgeType: TypeGithub,
+			repoURL:   "https://github.com/acme/rfds",
+			ssh:       "github.com:acme/rfds.git",
+			https:     "https://github.com/acme/rfds.git",
+			file:      "https://github.com/acme/rfds/blob/0042/rfds/0042/README.md",
+			branch:    "https://github.com/acme/rfds/tree/0042",
+			edit:      "https://github.dev/acme/rfds/blob/0042/rfds/0042/README.md",
+		},
+		{
+			forgeType: TypeGithub,
+			repoURL:   "https://github.example.com/acme/rfds.git",
+			ssh:       "github.example.com:acme/rfds.git",
+			https:     "https://github.example.com/acme/rfds.git",
+			file:      "https://github.example.com/acme/rfds/blob/0042/rfds/0042/README.md",
+			branch:    "https://github.example.com/acme/rfds/tree/0042",
+			edit:      "https://github.example.com/acme/rfds/edit/0042/rfds/0042/README.md",
+		},
+		{
+			forgeType: TypeGitlab,
+			repoURL:   "https://gitlab.com/acme/team/rfds",
+			ssh:       "gitlab.com:acme/team/rfds.git",
+			https:     "https://gitlab.com/acme/team/rfds.git",
+			file:      "https://gitlab.com/acme/team/rfds/-/blob/0042/rfds/0042/README.md",
+			branch:    "https://gitlab.com/acme/team/rfds/-/tree/0042",
+			edit:      "https://gitlab.com/-/ide/project/acme/team/rfds/edit/0042/-/rfds/0042/README.md",
+		},
+		{
+			forgeType: TypeGitea,
+			repoURL:   "http://git.example.com:3000/acme/rfds",
+			ssh:       "git.example.com:acme/rfds.git",
+			https:     "http://git.example.com:3000/acme/rfds.git",
+			file:      "http://git.example.com:3000/acme/rfds/src/branch/0042/rfds/0042/README.md",
+			branch:    "http://git.example.com:3000/acme/rfds/src/branch/0042",
+			edit:      "http://git.example.com:3000/acme/rfds/_edit/0042/rfds/0042/README.md",
+		},
+		{
+			forgeType: TypeGit,
+			repoURL:   "file:///srv/git/rfds.git",
+			ssh:       "file:///srv/git/rfds.git",
+			https:     "file:///srv/git/rfds.git",
+		},
+	}
+
+	for _, test := range tests {
+		f, err := New(Options{Type: test.forgeType, RepoURL: test.repoURL})
+		if err != nil {
+			t.Fatalf("Failed to create %s forge for %s: %v", test.forgeType, test.repoURL, err)
+		}
+
+		checks := map[string][2]string{
+			"ssh clone url":   {f.CloneURL(ProtocolSSH), test.ssh},
+			"https clone url": {f.CloneURL(ProtocolHTTPS), test.https},
+			"file url":        {f.FileURL("0042", "rfds/0042/README.md"), test.file},
+			"branch url":      {f.BranchURL("0042"), test.branch},
+			"edit url":        {f.EditURL("0042", "rfds/0042/README.md"), test.edit},
+		}
+
+		for name, check := range checks {
+			if check[0] != check[1] {
+				t.Errorf("%s %s: got %q, want %q", test.repoURL, name, check[0], check[1])
+			}
+		}
+	}
+}
+
+func TestHTTPSAuth(t *testing.T) {
+	tests := []struct {
+		forgeType string
+		username  string
+		want      string
+	}{
+		{TypeGithub, "", "x-access-token"},
+		{TypeGitlab, "", "oauth2"},
+		{TypeGitea, "", "acme"},
+		{TypeGitea, "rfd-bot", "rfd-bot"},
+	}
+
+	for _, test := range tests {
+		f, err := New(Options{Type: test.forgeType, RepoURL: "https://example.com/acme/rfds"})
+		if err != nil {
+			t.Fatalf("Failed to create %s forge: %v", test.forgeType, err)
+		}
+
+		method, err := f.Auth(ProtocolHTTPS, Credentials{Username: test.username, Token: "secret"})
+		if err != nil {
+			t.Fatalf("Failed to build %s auth: %v", test.forgeType, err)
+		}
+
+		basic, ok := method.(*githttp.BasicAuth)
+		if !ok || basic.Username != test.want || basic.Password != "secret" {
+			t.Errorf("%s: unexpected auth %v", test.forgeType, method)
+		}
+	}
+}
+
+func TestGitLocalRepo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rfds.git")
+	if _, err := git.PlainInit(path, true); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+
+	f := NewGit("file://" + path)
+
+	// A local repo needs no credentials, even over ssh
+	method, err := f.Auth(ProtocolSSH, Credentials{})
+	if err != nil || method != nil {
+		t.Fatalf("Expected no auth for a local repo, got %v, %v", method, err)
+	}
+
+	_, err = git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: f.CloneURL(ProtocolSSH), Auth: method})
+	if err != transport.ErrEmptyRemoteRepository {
+		t.Errorf("Expected to reach the empty local repo, got %v", err)
+	}
+
+	if _, err := f.OpenPullRequest(PullRequest{Head: "0042", Base: "main"}); err != ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
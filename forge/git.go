@@ -0,0 +1,50 @@
+package forge
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Git is any git remote without a web UI or API, such as a bare repo on disk.
+// It has no links and can't open pull requests.
+type Git struct {
+	url string
+}
+
+// NewGit creates a forge for the repo cloned from url, e.g. file:///srv/git/rfds.git
+func NewGit(url string) *Git {
+	return &Git{url: url}
+}
+
+// CloneURL is the repo URL as configured, whatever the protocol
+func (g *Git) CloneURL(protocol Protocol) string {
+	return g.url
+}
+
+// Auth returns what git should authenticate with over protocol. Local repos need none.
+func (g *Git) Auth(protocol Protocol, creds Credentials) (transport.AuthMethod, error) {
+	if isLocalURL(g.url) {
+		return nil, nil
+	}
+
+	return auth(protocol, creds, "git")
+}
+
+// FileURL is empty, there's no web UI
+func (g *Git) FileURL(branch string, path string) string {
+	return ""
+}
+
+// BranchURL is empty, there's no web UI
+func (g *Git) BranchURL(branch string) string {
+	return ""
+}
+
+// EditURL is empty, there's no online editor
+func (g *Git) EditURL(branch string, path string) string {
+	return ""
+}
+
+// OpenPullRequest isn't supported on a plain git remote
+func (g *Git) OpenPullRequest(pr PullRequest) (*PullRequestResult, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,116 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Gitea is a repo on a Gitea (or Forgejo) server
+type Gitea struct {
+	webRepo
+	owner string
+	repo  string
+	api   *apiClient
+}
+
+type giteaPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// NewGitea creates a Gitea client for the repo at repoURL.
+// apiURL defaults to /api/v1 on the repo's host.
+func NewGitea(apiURL string, token string, repoURL string) (*Gitea, error) {
+	repo, err := parseWebRepo(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, name, err := repo.ownerAndRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	if apiURL == "" {
+		apiURL = repo.base + "/api/v1"
+	}
+
+	return &Gitea{
+		webRepo: repo,
+		owner:   owner,
+		repo:    name,
+		api: newAPIClient(apiURL, map[string]string{
+			"Accept":        "application/json",
+			"Authorization": "token " + token,
+		}),
+	}, nil
+}
+
+// CloneURL is the URL git clones and pushes to over protocol
+func (g *Gitea) CloneURL(protocol Protocol) string {
+	return g.cloneURL(protocol)
+}
+
+// Auth returns what git should authenticate with over protocol.
+// Gitea wants the token's owner as the username, set it with Credentials.Username.
+func (g *Gitea) Auth(protocol Protocol, creds Credentials) (transport.AuthMethod, error) {
+	return auth(protocol, creds, g.owner)
+}
+
+// FileURL links to a file on a branch
+func (g *Gitea) FileURL(branch string, path string) string {
+	return fmt.Sprintf("%s/src/branch/%s/%s", g.url, branch, path)
+}
+
+// BranchURL links to a branch
+func (g *Gitea) BranchURL(branch string) string {
+	return fmt.Sprintf("%s/src/branch/%s", g.url, branch)
+}
+
+// EditURL opens the file in Gitea's web editor
+func (g *Gitea) EditURL(branch string, path string) string {
+	return fmt.Sprintf("%s/_edit/%s/%s", g.url, branch, path)
+}
+
+// OpenPullRequest opens a pull request, or returns the open one if the head branch already has one
+func (g *Gitea) OpenPullRequest(pr PullRequest) (*PullRequestResult, error) {
+	pulls := fmt.Sprintf("/repos/%s/%s/pulls", g.owner, g.repo)
+
+	// Older Gitea can't filter by branch, look through the open ones instead
+	var open []giteaPullRequest
+	if err := g.api.do(http.MethodGet, pulls+"?state=open&limit=50", nil, &open); err != nil {
+		return nil, err
+	}
+
+	for _, existing := range open {
+		if existing.Head.Ref == pr.Head && existing.Base.Ref == pr.Base {
+			return &PullRequestResult{Number: existing.Number, URL: existing.HTMLURL}, nil
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created giteaPullRequest
+	if err := g.api.do(http.MethodPost, pulls, body, &created); err != nil {
+		return nil, err
+	}
+
+	return &PullRequestResult{Number: created.Number, URL: created.HTMLURL}, nil
+}
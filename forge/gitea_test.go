@@ -0,0 +1,42 @@
+package forge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGiteaOpenPullRequestReturnsExisting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method != http.MethodGet || r.URL.Path != "/repos/acme/rfds/pulls" {
+			t.Errorf("Expected no pull request to be created, got %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`[
+			{"number": 2, "html_url": "https://git.example.com/acme/rfds/pulls/2", "head": {"ref": "0041"}, "base": {"ref": "main"}},
+			{"number": 3, "html_url": "https://git.example.com/acme/rfds/pulls/3", "head": {"ref": "0042"}, "base": {"ref": "main"}}
+		]`))
+	}))
+	defer server.Close()
+
+	gitea, err := NewGitea(server.URL, "secret-token", "https://git.example.com/acme/rfds")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	result, err := gitea.OpenPullRequest(PullRequest{Head: "0042", Base: "main"})
+	if err != nil {
+		t.Fatalf("Failed to open pull request: %v", err)
+	}
+
+	if result.Number != 3 || result.URL != "https://git.example.com/acme/rfds/pulls/3" {
+		t.Errorf("Expected existing pull request, got %+v", result)
+	}
+}
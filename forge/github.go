@@ -0,0 +1,135 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// DefaultGithubAPIURL is used when forge.apiUrl isn't set and the repo is on github.com
+const DefaultGithubAPIURL = "https://api.github.com"
+
+// Github is a repo on github.com or GitHub Enterprise
+type Github struct {
+	webRepo
+	owner string
+	repo  string
+	api   *apiClient
+}
+
+type githubPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// NewGithub creates a GitHub client for the repo at repoURL.
+// apiURL defaults to api.github.com, or /api/v3 on the repo's host for GitHub Enterprise.
+func NewGithub(apiURL string, token string, repoURL string) (*Github, error) {
+	repo, err := parseWebRepo(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, name, err := repo.ownerAndRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	if apiURL == "" {
+		apiURL = DefaultGithubAPIURL
+		if repo.host != "github.com" {
+			apiURL = repo.base + "/api/v3"
+		}
+	}
+
+	return &Github{
+		webRepo: repo,
+		owner:   owner,
+		repo:    name,
+		api: newAPIClient(apiURL, map[string]string{
+			"Accept":               "application/vnd.github+json",
+			"X-GitHub-Api-Version": "2022-11-28",
+			"Authorization":        "Bearer " + token,
+		}),
+	}, nil
+}
+
+// CloneURL is the URL git clones and pushes to over protocol
+func (g *Github) CloneURL(protocol Protocol) string {
+	return g.cloneURL(protocol)
+}
+
+// Auth returns what git should authenticate with over protocol
+func (g *Github) Auth(protocol Protocol, creds Credentials) (transport.AuthMethod, error) {
+	return auth(protocol, creds, "x-access-token")
+}
+
+// FileURL links to a file on a branch
+func (g *Github) FileURL(branch string, path string) string {
+	return fmt.Sprintf("%s/blob/%s/%s", g.url, branch, path)
+}
+
+// BranchURL links to a branch
+func (g *Github) BranchURL(branch string) string {
+	return fmt.Sprintf("%s/tree/%s", g.url, branch)
+}
+
+// EditURL opens the file in github.dev, or the web editor on GitHub Enterprise
+func (g *Github) EditURL(branch string, path string) string {
+	if g.host == "github.com" {
+		// https://github.dev/geekgonecrazy/rfd-example/blob/0005/rfds/0005/README.md
+		return fmt.Sprintf("https://github.dev/%s/blob/%s/%s", g.path, branch, path)
+	}
+
+	return fmt.Sprintf("%s/edit/%s/%s", g.url, branch, path)
+}
+
+// OpenPullRequest opens a pull request, or returns the open one if the head branch already has one
+func (g *Github) OpenPullRequest(pr PullRequest) (*PullRequestResult, error) {
+	existing, err := g.findPullRequest(pr.Head, pr.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created githubPullRequest
+	if err := g.api.do(http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls", g.owner, g.repo), body, &created); err != nil {
+		return nil, err
+	}
+
+	return &PullRequestResult{Number: created.Number, URL: created.HTMLURL}, nil
+}
+
+func (g *Github) findPullRequest(head string, base string) (*PullRequestResult, error) {
+	query := url.Values{}
+	query.Set("state", "open")
+	query.Set("head", fmt.Sprintf("%s:%s", g.owner, head))
+	query.Set("base", base)
+
+	var open []githubPullRequest
+	if err := g.api.do(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls?%s", g.owner, g.repo, query.Encode()), nil, &open); err != nil {
+		return nil, err
+	}
+
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	return &PullRequestResult{Number: open[0].Number, URL: open[0].HTMLURL}, nil
+}
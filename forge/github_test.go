@@ -0,0 +1,98 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGithubOpenPullRequest(t *testing.T) {
+	var created map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/repos/acme/rfds/pulls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Get("head") != "acme:0042" || r.URL.Query().Get("state") != "open" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[]`))
+		case http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number": 7, "html_url": "https://github.com/acme/rfds/pull/7"}`))
+		}
+	}))
+	defer server.Close()
+
+	github, err := NewGithub(server.URL, "secret-token", "https://github.com/acme/rfds")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	result, err := github.OpenPullRequest(PullRequest{Title: "RFD 0042: Things", Head: "0042", Base: "main"})
+	if err != nil {
+		t.Fatalf("Failed to open pull request: %v", err)
+	}
+
+	if result.Number != 7 || result.URL != "https://github.com/acme/rfds/pull/7" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if created["head"] != "0042" || created["base"] != "main" || created["title"] != "RFD 0042: Things" {
+		t.Errorf("Unexpected pull request payload: %+v", created)
+	}
+}
+
+func TestGithubOpenPullRequestReturnsExisting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected no pull request to be created, got %s", r.Method)
+		}
+		w.Write([]byte(`[{"number": 3, "html_url": "https://github.com/acme/rfds/pull/3"}]`))
+	}))
+	defer server.Close()
+
+	github, err := NewGithub(server.URL, "token", "https://github.com/acme/rfds.git")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	result, err := github.OpenPullRequest(PullRequest{Head: "0042", Base: "main"})
+	if err != nil {
+		t.Fatalf("Failed to open pull request: %v", err)
+	}
+
+	if result.Number != 3 {
+		t.Errorf("Expected existing pull request, got %+v", result)
+	}
+}
+
+func TestGithubAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Validation Failed"}`))
+	}))
+	defer server.Close()
+
+	github, err := NewGithub(server.URL, "token", "https://github.com/acme/rfds")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = github.OpenPullRequest(PullRequest{Head: "0042", Base: "main"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Message != "Validation Failed" {
+		t.Errorf("Expected API error, got %v", err)
+	}
+}
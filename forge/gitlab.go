@@ -0,0 +1,103 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Gitlab is a repo on gitlab.com or a self hosted GitLab
+type Gitlab struct {
+	webRepo
+	api *apiClient
+}
+
+type gitlabMergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+// NewGitlab creates a GitLab client for the repo at repoURL, which may be in nested groups.
+// apiURL defaults to /api/v4 on the repo's host.
+func NewGitlab(apiURL string, token string, repoURL string) (*Gitlab, error) {
+	repo, err := parseWebRepo(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiURL == "" {
+		apiURL = repo.base + "/api/v4"
+	}
+
+	return &Gitlab{
+		webRepo: repo,
+		api: newAPIClient(apiURL, map[string]string{
+			"Accept":        "application/json",
+			"PRIVATE-TOKEN": token,
+		}),
+	}, nil
+}
+
+// CloneURL is the URL git clones and pushes to over protocol
+func (g *Gitlab) CloneURL(protocol Protocol) string {
+	return g.cloneURL(protocol)
+}
+
+// Auth returns what git should authenticate with over protocol
+func (g *Gitlab) Auth(protocol Protocol, creds Credentials) (transport.AuthMethod, error) {
+	return auth(protocol, creds, "oauth2")
+}
+
+// FileURL links to a file on a branch
+func (g *Gitlab) FileURL(branch string, path string) string {
+	return fmt.Sprintf("%s/-/blob/%s/%s", g.url, branch, path)
+}
+
+// BranchURL links to a branch
+func (g *Gitlab) BranchURL(branch string) string {
+	return fmt.Sprintf("%s/-/tree/%s", g.url, branch)
+}
+
+// EditURL opens the file in GitLab's Web IDE
+func (g *Gitlab) EditURL(branch string, path string) string {
+	return fmt.Sprintf("%s/-/ide/project/%s/edit/%s/-/%s", g.base, g.path, branch, path)
+}
+
+// OpenPullRequest opens a merge request, or returns the open one if the head branch already has one
+func (g *Gitlab) OpenPullRequest(pr PullRequest) (*PullRequestResult, error) {
+	project := "/projects/" + url.PathEscape(g.path)
+
+	query := url.Values{}
+	query.Set("state", "opened")
+	query.Set("source_branch", pr.Head)
+	query.Set("target_branch", pr.Base)
+
+	var open []gitlabMergeRequest
+	if err := g.api.do(http.MethodGet, project+"/merge_requests?"+query.Encode(), nil, &open); err != nil {
+		return nil, err
+	}
+
+	if len(open) > 0 {
+		return &PullRequestResult{Number: open[0].IID, URL: open[0].WebURL}, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"title":         pr.Title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created gitlabMergeRequest
+	if err := g.api.do(http.MethodPost, project+"/merge_requests", body, &created); err != nil {
+		return nil, err
+	}
+
+	return &PullRequestResult{Number: created.IID, URL: created.WebURL}, nil
+}
@@ -0,0 +1,75 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitlabOpenMergeRequest(t *testing.T) {
+	var created map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// Nested groups are escaped into a single path segment
+		if r.URL.EscapedPath() != "/projects/acme%2Fteam%2Frfds/merge_requests" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Get("source_branch") != "0042" || r.URL.Query().Get("state") != "opened" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[]`))
+		case http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"iid": 5, "web_url": "https://gitlab.com/acme/team/rfds/-/merge_requests/5"}`))
+		}
+	}))
+	defer server.Close()
+
+	gitlab, err := NewGitlab(server.URL, "secret-token", "https://gitlab.com/acme/team/rfds")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	result, err := gitlab.OpenPullRequest(PullRequest{Title: "RFD 0042: Things", Body: "link", Head: "0042", Base: "main"})
+	if err != nil {
+		t.Fatalf("Failed to open merge request: %v", err)
+	}
+
+	if result.Number != 5 || result.URL != "https://gitlab.com/acme/team/rfds/-/merge_requests/5" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if created["source_branch"] != "0042" || created["target_branch"] != "main" || created["description"] != "link" {
+		t.Errorf("Unexpected merge request payload: %+v", created)
+	}
+}
+
+func TestGitlabAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message": ["Another open merge request already exists for this source branch"]}`))
+	}))
+	defer server.Close()
+
+	gitlab, err := NewGitlab(server.URL, "token", "https://gitlab.com/acme/rfds")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = gitlab.OpenPullRequest(PullRequest{Head: "0042", Base: "main"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusConflict || apiErr.Message == "" {
+		t.Errorf("Expected API error with a message, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// APITokenScope is part of the API an API token may use
+type APITokenScope string
+
+const (
+	ScopeRFDsRead  APITokenScope = "rfds:read"  // Read RFDs, tags, authors, templates and search
+	ScopeRFDsWrite APITokenScope = "rfds:write" // Create and update RFDs, change their state and upload files
+	ScopeAdmin     APITokenScope = "admin"      // Everything, including triggering a sync
+)
+
+// AllAPITokenScopes in the order they're offered when creating a token
+var AllAPITokenScopes = []APITokenScope{ScopeRFDsRead, ScopeRFDsWrite, ScopeAdmin}
+
+// scopeRoles is the role a token's owner needs for each scope to work
+var scopeRoles = map[APITokenScope]Role{
+	ScopeRFDsRead:  RoleViewer,
+	ScopeRFDsWrite: RoleAuthor,
+	ScopeAdmin:     RoleAdmin,
+}
+
+func (s APITokenScope) Valid() bool {
+	_, ok := scopeRoles[s]
+	return ok
+}
+
+// Role is the role a token's owner needs for the scope to work
+func (s APITokenScope) Role() Role {
+	return scopeRoles[s]
+}
+
+// APITokenScopes are the scopes granted to a token
+type APITokenScopes []APITokenScope
+
+// ScopesForRole is every scope role allows, what a signed in session can do with the API
+func ScopesForRole(role Role) APITokenScopes {
+	scopes := APITokenScopes{}
+	for _, scope := range AllAPITokenScopes {
+		if role.Allows(scope.Role()) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
+// Allows reports whether the scopes cover required. admin covers everything
+// and rfds:write covers rfds:read.
+func (s APITokenScopes) Allows(required APITokenScope) bool {
+	for _, scope := range s {
+		if scope == required || scope == ScopeAdmin || (scope == ScopeRFDsWrite && required == ScopeRFDsRead) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIToken is a personal token for the API, owned by the user who created it.
+// Only a hash of the token is stored, it's shown once when created.
+type APIToken struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`   // What it's for, e.g. "CI"
+	Owner  string `json:"owner"`  // Email of the user who created it, writes made with it are attributed to them
+	Prefix string `json:"prefix"` // Start of the token, to tell tokens apart without the whole thing
+	Hash   string `json:"-"`
+
+	// The owner's groups when the token was created, they decide the owner's role and which RFDs the token can see.
+	// Each time the owner signs in they're checked against the owner's current groups, and the token is revoked if
+	// any are gone.
+	OwnerGroups     []string  `json:"-"`
+	GroupsCheckedAt time.Time `json:"groupsCheckedAt"` // When the owner last signed in, or the token was created
+
+	Scopes     APITokenScopes `json:"scopes"`
+	ExpiresAt  *time.Time     `json:"expiresAt,omitempty"` // Never expires when nil
+	LastUsedAt *time.Time     `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time     `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
+// Active reports whether the token can be used at now
+func (t APIToken) Active(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
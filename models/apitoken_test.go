@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAPITokenScopesAllows(t *testing.T) {
+	cases := []struct {
+		scopes   APITokenScopes
+		required APITokenScope
+		allowed  bool
+	}{
+		{APITokenScopes{ScopeRFDsRead}, ScopeRFDsRead, true},
+		{APITokenScopes{ScopeRFDsRead}, ScopeRFDsWrite, false},
+		{APITokenScopes{ScopeRFDsWrite}, ScopeRFDsRead, true},
+		{APITokenScopes{ScopeRFDsWrite}, ScopeAdmin, false},
+		{APITokenScopes{ScopeAdmin}, ScopeRFDsWrite, true},
+		{APITokenScopes{}, ScopeRFDsRead, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.scopes.Allows(tc.required); got != tc.allowed {
+			t.Errorf("%v allows %s: expected %v, got %v", tc.scopes, tc.required, tc.allowed, got)
+		}
+	}
+}
+
+func TestScopesForRole(t *testing.T) {
+	cases := []struct {
+		role  Role
+		read  bool
+		write bool
+		admin bool
+	}{
+		{RoleViewer, true, false, false},
+		{RoleAuthor, true, true, false},
+		{RoleAdmin, true, true, true},
+		{Role(""), false, false, false},
+	}
+
+	for _, tc := range cases {
+		scopes := ScopesForRole(tc.role)
+
+		if got := scopes.Allows(ScopeRFDsRead); got != tc.read {
+			t.Errorf("%q: expected read=%v, got %v", tc.role, tc.read, got)
+		}
+
+		if got := scopes.Allows(ScopeRFDsWrite); got != tc.write {
+			t.Errorf("%q: expected write=%v, got %v", tc.role, tc.write, got)
+		}
+
+		if got := scopes.Allows(ScopeAdmin); got != tc.admin {
+			t.Errorf("%q: expected admin=%v, got %v", tc.role, tc.admin, got)
+		}
+	}
+}
+
+func TestAPITokenActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name   string
+		token  APIToken
+		active bool
+	}{
+		{"no expiry", APIToken{}, true},
+		{"expires later", APIToken{ExpiresAt: &future}, true},
+		{"expired", APIToken{ExpiresAt: &past}, false},
+		{"revoked", APIToken{ExpiresAt: &future, RevokedAt: &past}, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.token.Active(now); got != tc.active {
+			t.Errorf("%s: expected active=%v, got %v", tc.name, tc.active, got)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RFDFile is an attachment stored alongside an RFD's README.md, such as an image
+type RFDFile struct {
+	RFDID       string    `json:"rfdId"`
+	Path        string    `json:"path"` // Relative to the RFD's folder, e.g. diagram.png or images/flow.svg
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA         string    `json:"sha"` // Git blob hash of the content, so repo and uploaded copies compare equal
+	Content     []byte    `json:"-"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
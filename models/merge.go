@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RFDMerge records an RFD's branch being merged into the main branch
+type RFDMerge struct {
+	ID          int64     `json:"id"`
+	RFDID       string    `json:"rfdId"`
+	Branch      string    `json:"branch"`
+	CommitSHA   string    `json:"commitSha"`   // Merge commit, or the branch head when fast-forwarded
+	FastForward bool      `json:"fastForward"` // Main was fast-forwarded instead of getting a merge commit
+	Actor       string    `json:"actor,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ShortCommitSHA returns the abbreviated commit SHA for display
+func (m RFDMerge) ShortCommitSHA() string {
+	if len(m.CommitSHA) > 7 {
+		return m.CommitSHA[:7]
+	}
+
+	return m.CommitSHA
+}
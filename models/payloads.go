@@ -4,4 +4,29 @@ type RFDCreatePayload struct {
 	Title   string `json:"title" form:"title" binding:"required"`
 	Authors string `json:"authors" form:"authors" binding:"required"`
 	Tags    string `json:"tags" form:"tags"`
+
+	// Template is the name of the template to start from, TEMPLATE.md or the first in templates/ when empty
+	Template string `json:"template" form:"template"`
+}
+
+type RFDStatePayload struct {
+	State RFDState `json:"state" form:"state" binding:"required"`
+}
+
+// RFDEditPayload is the in-browser editor form
+type RFDEditPayload struct {
+	Title      string `json:"title" form:"title" binding:"required"`
+	Authors    string `json:"authors" form:"authors" binding:"required"`
+	Tags       string `json:"tags" form:"tags"`
+	Public     bool   `json:"public" form:"public"`
+	Body       string `json:"body" form:"body"`
+	Message    string `json:"message" form:"message"`       // Commit message, optional
+	BaseCommit string `json:"baseCommit" form:"baseCommit"` // Commit the editor was loaded from
+}
+
+// APITokenPayload is the form for creating an API token
+type APITokenPayload struct {
+	Name          string   `json:"name" form:"name" binding:"required"`
+	Scopes        []string `json:"scopes" form:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expiresInDays" form:"expiresInDays"` // 0 never expires
 }
@@ -0,0 +1,46 @@
+package models
+
+// RFDRelationKind is how an RFD refers to another one in its frontmatter
+type RFDRelationKind string
+
+const (
+	Supersedes RFDRelationKind = "supersedes"
+	DependsOn  RFDRelationKind = "depends_on"
+	Related    RFDRelationKind = "related"
+)
+
+func (k RFDRelationKind) Valid() bool {
+	return k == Supersedes || k == DependsOn || k == Related
+}
+
+// RFDRelation is a link from RFDID to TargetID, declared in RFDID's frontmatter
+type RFDRelation struct {
+	RFDID    string          `json:"rfdId"`
+	Kind     RFDRelationKind `json:"kind"`
+	TargetID string          `json:"targetId"`
+}
+
+// RFDRelationLink is the RFD on the other end of a relation
+type RFDRelationLink struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	State RFDState `json:"state"`
+}
+
+// RFDRelations is every RFD related to an RFD, including the inverse
+// links from RFDs that point at it
+type RFDRelations struct {
+	Supersedes   []RFDRelationLink `json:"supersedes"`
+	SupersededBy []RFDRelationLink `json:"supersededBy"`
+	DependsOn    []RFDRelationLink `json:"dependsOn"`
+	RequiredBy   []RFDRelationLink `json:"requiredBy"` // RFDs that depend on this one
+	Related      []RFDRelationLink `json:"related"`    // Related goes both ways
+
+	// Relations the RFD declares to RFDs that don't exist, flagged so its authors can fix them
+	Missing []RFDRelation `json:"missing"`
+}
+
+// IsEmpty reports whether the RFD isn't related to anything
+func (r RFDRelations) IsEmpty() bool {
+	return len(r.Supersedes) == 0 && len(r.SupersededBy) == 0 && len(r.DependsOn) == 0 && len(r.RequiredBy) == 0 && len(r.Related) == 0
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RepoWriteKind is what a queued repo write does
+type RepoWriteKind string
+
+const (
+	// RepoWriteDiscussionLink commits an RFD's discussion link to its README.md
+	RepoWriteDiscussionLink RepoWriteKind = "discussion_link"
+)
+
+// RepoWriteStatus is where a queued repo write is at
+type RepoWriteStatus string
+
+const (
+	RepoWritePending RepoWriteStatus = "pending" // Waiting for its next attempt
+	RepoWriteDone    RepoWriteStatus = "done"
+	RepoWriteFailed  RepoWriteStatus = "failed" // Gave up after too many attempts, needs a manual retry
+)
+
+// RepoWrite is a change to the RFD repo queued in the database, so it
+// survives failed pushes and restarts until it's been committed
+type RepoWrite struct {
+	ID            int64           `json:"id"`
+	Kind          RepoWriteKind   `json:"kind"`
+	RFDID         string          `json:"rfdId"`
+	Value         string          `json:"value"` // e.g. the discussion URL
+	Status        RepoWriteStatus `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"lastError,omitempty"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
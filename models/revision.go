@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// RFDRevision is a snapshot of an RFD, taken every time it changes
+type RFDRevision struct {
+	ID         int64    `json:"id"`
+	RFDID      string   `json:"rfdId"`
+	Title      string   `json:"title"`
+	State      RFDState `json:"state"`
+	Discussion string   `json:"discussion"`
+	Tags       []string `json:"tags"`
+	Authors    []Author `json:"authors"`
+	Public     bool     `json:"public"`
+	ContentMD  string   `json:"contentMD"`
+
+	CommitSHA string    `json:"commitSha,omitempty"` // Commit the content came from, if known
+	Actor     string    `json:"actor,omitempty"`     // Who or what triggered the change
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ChangeSource describes where a change to an RFD came from
+type ChangeSource struct {
+	Actor     string
+	CommitSHA string
+}
+
+// NewRFDRevision snapshots an RFD
+func NewRFDRevision(rfd *RFD, source *ChangeSource) *RFDRevision {
+	revision := &RFDRevision{
+		RFDID:      rfd.ID,
+		Title:      rfd.Title,
+		State:      rfd.State,
+		Discussion: rfd.Discussion,
+		Tags:       rfd.Tags,
+		Authors:    rfd.Authors,
+		Public:     rfd.Public,
+		ContentMD:  rfd.ContentMD,
+	}
+
+	if source != nil {
+		revision.Actor = source.Actor
+		revision.CommitSHA = source.CommitSHA
+	}
+
+	return revision
+}
+
+// AsRFD returns the RFD as it was at this revision
+func (r RFDRevision) AsRFD() *RFD {
+	return &RFD{
+		ID: r.RFDID,
+		RFDMeta: RFDMeta{
+			Title:      r.Title,
+			Authors:    r.Authors,
+			State:      r.State,
+			Discussion: r.Discussion,
+			Tags:       r.Tags,
+			Public:     r.Public,
+		},
+		ContentMD: r.ContentMD,
+	}
+}
+
+// ShortCommitSHA returns the abbreviated commit SHA for display
+func (r RFDRevision) ShortCommitSHA() string {
+	if len(r.CommitSHA) > 7 {
+		return r.CommitSHA[:7]
+	}
+
+	return r.CommitSHA
+}
@@ -16,6 +16,10 @@ type RFD struct {
 	// AuthorStrings is used temporarily during import/parsing to hold author strings from YAML
 	// This is not stored in DB and not included in JSON responses
 	AuthorStrings []string `json:"authorStrings" yaml:"-"`
+
+	// Relations declared in the frontmatter, stored in their own table.
+	// Only set on RFDs fresh from the renderer or sent by rfd-client.
+	Relations []RFDRelation `json:"relations,omitempty" yaml:"-"`
 }
 
 type RFDMeta struct {
@@ -25,6 +29,7 @@ type RFDMeta struct {
 	Discussion string   `json:"discussion" yaml:"discussion"`
 	Tags       []string `json:"tags" yaml:"tags"`
 	Public     bool     `json:"public" yaml:"public"`
+	Visibility []string `json:"visibility,omitempty" yaml:"visibility"` // Groups the RFD is restricted to, empty for everyone
 }
 
 // RFDMetaYAML is used for parsing YAML frontmatter with string authors
@@ -35,6 +40,10 @@ type RFDMetaYAML struct {
 	Discussion string   `yaml:"discussion"`
 	Tags       []string `yaml:"tags"`
 	Public     bool     `yaml:"public"`
+	Visibility []string `yaml:"visibility,omitempty"`
+	Supersedes []string `yaml:"supersedes,omitempty"`
+	DependsOn  []string `yaml:"depends_on,omitempty"`
+	Related    []string `yaml:"related,omitempty"`
 }
 
 type RFDState string
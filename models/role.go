@@ -0,0 +1,26 @@
+package models
+
+// Role is what a signed in user is allowed to do, each role can do everything the ones before it can
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // Read every RFD
+	RoleAuthor Role = "author" // Create, edit and change the state of RFDs
+	RoleAdmin  Role = "admin"  // Everything, including the admin pages
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleAuthor: 2,
+	RoleAdmin:  3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether r can do what required can
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role     Role
+		required Role
+		allowed  bool
+	}{
+		{RoleAdmin, RoleAuthor, true},
+		{RoleAuthor, RoleAuthor, true},
+		{RoleViewer, RoleAuthor, false},
+		{RoleAuthor, RoleAdmin, false},
+		{"", RoleViewer, false},
+		{"owner", RoleViewer, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.role.Allows(tc.required); got != tc.allowed {
+			t.Errorf("%q allows %q: expected %v, got %v", tc.role, tc.required, tc.allowed, got)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"html"
+	"strings"
+)
+
+// Markers stores wrap matched terms in, swapped for <mark> after escaping
+const (
+	SearchHighlightStart = "\x02"
+	SearchHighlightEnd   = "\x03"
+)
+
+// SearchOptions narrows a full text search
+type SearchOptions struct {
+	PublicOnly bool
+	Limit      int
+	Offset     int // Hits to skip, for fetching the next page
+}
+
+// SearchResult is a single full text search hit
+type SearchResult struct {
+	RFD     RFD     `json:"rfd"`
+	Snippet string  `json:"snippet"` // HTML, matched terms wrapped in <mark>
+	Rank    float64 `json:"rank"`
+}
+
+// HighlightSnippet escapes a raw snippet from the store and turns the highlight markers into <mark> tags
+func HighlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, SearchHighlightStart, "<mark>")
+	snippet = strings.ReplaceAll(snippet, SearchHighlightEnd, "</mark>")
+
+	return snippet
+}
@@ -13,14 +13,17 @@ type SessionToken struct {
 }
 
 type SessionUser struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Staff    bool   `json:"staff"`
-	LoggedIn bool   `json:"loggedIn"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups,omitempty"` // From the OIDC groups claim, mapped to a role on each request
+	Staff    bool     `json:"staff"`
+	LoggedIn bool     `json:"loggedIn"`
 }
 
 // Extract custom claims
 type IDTokenClaims struct {
-	Email    string `json:"email"`
-	Verified bool   `json:"email_verified"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Verified bool     `json:"email_verified"`
+	Groups   []string `json:"groups"`
 }
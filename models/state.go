@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateTransitions maps each state to the states an RFD may move to from it
+type StateTransitions map[RFDState][]RFDState
+
+// DefaultStateTransitions is the RFD process when states.transitions isn't configured
+var DefaultStateTransitions = StateTransitions{
+	PreDiscussion: {Ideation, Discussion, Abandoned},
+	Ideation:      {PreDiscussion, Discussion, Abandoned},
+	Discussion:    {Ideation, Published, Committed, Abandoned},
+	Published:     {Discussion, Committed, Abandoned},
+	Committed:     {Discussion, Abandoned},
+	Abandoned:     {Ideation, PreDiscussion},
+}
+
+// ParseStateTransitions builds transitions from config, checking every state is known
+func ParseStateTransitions(raw map[string][]string) (StateTransitions, error) {
+	transitions := StateTransitions{}
+
+	for from, tos := range raw {
+		fromState := RFDState(from)
+		if !fromState.Valid() {
+			return nil, fmt.Errorf("unknown state '%s'", from)
+		}
+
+		for _, to := range tos {
+			toState := RFDState(to)
+			if !toState.Valid() {
+				return nil, fmt.Errorf("unknown state '%s' in transitions from '%s'", to, from)
+			}
+
+			transitions[fromState] = append(transitions[fromState], toState)
+		}
+	}
+
+	return transitions, nil
+}
+
+// Allowed reports whether an RFD may move from one state to another.
+// Staying put is always allowed, and a new RFD may start in any valid state.
+func (t StateTransitions) Allowed(from RFDState, to RFDState) bool {
+	if !to.Valid() {
+		return false
+	}
+
+	if from == "" || from == to {
+		return true
+	}
+
+	for _, allowed := range t[from] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StateTransition records an RFD moving from one state to another
+type StateTransition struct {
+	ID        int64     `json:"id"`
+	RFDID     string    `json:"rfdId"`
+	From      RFDState  `json:"from"` // Empty when the RFD was created
+	To        RFDState  `json:"to"`
+	Allowed   bool      `json:"allowed"` // False when an illegal transition was let through
+	Actor     string    `json:"actor,omitempty"`
+	CommitSHA string    `json:"commitSha,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
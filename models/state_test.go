@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestDefaultStateTransitions(t *testing.T) {
+	cases := []struct {
+		from    RFDState
+		to      RFDState
+		allowed bool
+	}{
+		{"", Ideation, true},
+		{"", "bogus", false},
+		{Ideation, Ideation, true},
+		{Ideation, Discussion, true},
+		{Discussion, Committed, true},
+		{Abandoned, Committed, false},
+		{Ideation, Committed, false},
+		{Discussion, "bogus", false},
+	}
+
+	for _, tc := range cases {
+		if got := DefaultStateTransitions.Allowed(tc.from, tc.to); got != tc.allowed {
+			t.Errorf("%q -> %q: expected allowed=%v, got %v", tc.from, tc.to, tc.allowed, got)
+		}
+	}
+}
+
+func TestParseStateTransitions(t *testing.T) {
+	transitions, err := ParseStateTransitions(map[string][]string{
+		"ideation":   {"discussion"},
+		"discussion": {"committed"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse transitions: %v", err)
+	}
+
+	if !transitions.Allowed(Ideation, Discussion) || transitions.Allowed(Ideation, Abandoned) {
+		t.Errorf("Unexpected transitions: %+v", transitions)
+	}
+
+	if _, err := ParseStateTransitions(map[string][]string{"ideation": {"done"}}); err == nil {
+		t.Error("Expected error for unknown state")
+	}
+}
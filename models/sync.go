@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SyncStatus reports on the server side repo sync loop
+type SyncStatus struct {
+	Enabled  bool   `json:"enabled"`
+	Running  bool   `json:"running"`
+	Interval string `json:"interval"`
+
+	LastStartedAt  *time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt,omitempty"`
+	LastSuccessAt  *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+
+	// Counts from the most recent run
+	Checked int `json:"checked"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+}
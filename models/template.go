@@ -0,0 +1,11 @@
+package models
+
+// RFDTemplate is a template in the RFD repo new RFDs can be created from.
+// State, Tags and Public come from its frontmatter and are the new RFD's defaults.
+type RFDTemplate struct {
+	Name   string   `json:"name"` // What RFDCreatePayload.Template refers to
+	Path   string   `json:"path"`
+	State  RFDState `json:"state"`
+	Tags   []string `json:"tags"`
+	Public bool     `json:"public"`
+}
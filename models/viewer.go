@@ -0,0 +1,52 @@
+package models
+
+import "strings"
+
+// Viewer is who RFDs are being shown to
+type Viewer struct {
+	LoggedIn bool
+	Email    string
+	Groups   []string
+
+	// All sees every RFD whatever its visibility, for the API secret which isn't tied to a user
+	All bool
+}
+
+// Restricted reports whether the RFD is only visible to some groups
+func (r RFD) Restricted() bool {
+	return len(r.Visibility) > 0
+}
+
+// VisibleTo reports whether viewer may see the RFD. Restricted RFDs are never public,
+// only signed in members of one of their groups and their own authors can see them.
+func (r RFD) VisibleTo(viewer Viewer) bool {
+	if viewer.All {
+		return true
+	}
+
+	if !r.Restricted() {
+		return viewer.LoggedIn || r.Public
+	}
+
+	if !viewer.LoggedIn {
+		return false
+	}
+
+	for _, group := range r.Visibility {
+		for _, viewerGroup := range viewer.Groups {
+			if strings.EqualFold(group, viewerGroup) {
+				return true
+			}
+		}
+	}
+
+	if viewer.Email != "" {
+		for _, author := range r.Authors {
+			if strings.EqualFold(author.Email, viewer.Email) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
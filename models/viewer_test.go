@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestRFDVisibleTo(t *testing.T) {
+	open := RFD{RFDMeta: RFDMeta{Public: false}}
+	public := RFD{RFDMeta: RFDMeta{Public: true}}
+	restricted := RFD{RFDMeta: RFDMeta{
+		Public:     true,
+		Visibility: []string{"security-team"},
+		Authors:    []Author{{Email: "author@example.com"}},
+	}}
+
+	anonymous := Viewer{}
+	member := Viewer{LoggedIn: true, Email: "member@example.com", Groups: []string{"Security-Team"}}
+	outsider := Viewer{LoggedIn: true, Email: "outsider@example.com", Groups: []string{"engineering"}}
+	author := Viewer{LoggedIn: true, Email: "Author@example.com"}
+	api := Viewer{All: true}
+
+	cases := []struct {
+		name    string
+		rfd     RFD
+		viewer  Viewer
+		visible bool
+	}{
+		{"private rfd, anonymous", open, anonymous, false},
+		{"private rfd, signed in", open, outsider, true},
+		{"public rfd, anonymous", public, anonymous, true},
+		{"restricted rfd, anonymous", restricted, anonymous, false},
+		{"restricted rfd, group member", restricted, member, true},
+		{"restricted rfd, other group", restricted, outsider, false},
+		{"restricted rfd, author", restricted, author, true},
+		{"restricted rfd, api", restricted, api, true},
+	}
+
+	for _, tc := range cases {
+		if got := tc.rfd.VisibleTo(tc.viewer); got != tc.visible {
+			t.Errorf("%s: expected visible=%v, got %v", tc.name, tc.visible, got)
+		}
+	}
+}
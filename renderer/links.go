@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// rfdNumKey holds the number of the RFD being rendered, for rfdFileLinks
+var rfdNumKey = parser.NewContextKey()
+
+// rfdFileLinks points relative image and link destinations, such as
+// ![](diagram.png), at the RFD's attachments served from /NNNN/files/
+type rfdFileLinks struct{}
+
+// Transform rewrites the destinations in the Markdown AST
+func (t *rfdFileLinks) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	rfdNum, ok := pc.Get(rfdNumKey).(string)
+	if !ok || rfdNum == "" {
+		return
+	}
+
+	ast.Walk(node, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Image:
+			n.Destination = rfdFileDestination(rfdNum, n.Destination)
+		case *ast.Link:
+			n.Destination = rfdFileDestination(rfdNum, n.Destination)
+		}
+
+		return ast.WalkContinue, nil
+	})
+}
+
+// RFDFileURL is where an RFD's attachment is served
+func RFDFileURL(rfdNum string, filePath string) string {
+	return (&url.URL{Path: "/" + rfdNum + "/files/" + filePath}).EscapedPath()
+}
+
+// rfdFileDestination rewrites a destination relative to the RFD's folder.
+// Anything else, such as absolute URLs, anchors, other markdown files and
+// paths leading out of the folder, is left alone.
+func rfdFileDestination(rfdNum string, destination []byte) []byte {
+	u, err := url.Parse(string(destination))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return destination
+	}
+
+	filePath := path.Clean(u.Path)
+	if filePath == "." || filePath == ".." || strings.HasPrefix(filePath, "../") || strings.HasSuffix(strings.ToLower(filePath), ".md") {
+		return destination
+	}
+
+	rewritten := RFDFileURL(rfdNum, filePath)
+	if u.RawQuery != "" {
+		rewritten += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		rewritten += "#" + u.EscapedFragment()
+	}
+
+	return []byte(rewritten)
+}
@@ -0,0 +1,53 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// parseRelations turns the RFD numbers listed under each relation in the frontmatter
+// into relations, in a stable order. Numbers are written however is convenient
+// (7, 0007, "RFD 7") so they're normalized. Core decides which ones are valid.
+func parseRelations(rfdNum string, refs map[models.RFDRelationKind][]string) []models.RFDRelation {
+	relations := []models.RFDRelation{}
+	seen := map[models.RFDRelation]bool{}
+
+	for kind, targets := range refs {
+		for _, target := range targets {
+			relation := models.RFDRelation{RFDID: rfdNum, Kind: kind, TargetID: normalizeRFDRef(target)}
+			if relation.TargetID == "" || seen[relation] {
+				continue
+			}
+
+			seen[relation] = true
+			relations = append(relations, relation)
+		}
+	}
+
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].Kind != relations[j].Kind {
+			return relations[i].Kind < relations[j].Kind
+		}
+		return relations[i].TargetID < relations[j].TargetID
+	})
+
+	return relations
+}
+
+// normalizeRFDRef strips an optional RFD prefix and pads the number to four digits
+func normalizeRFDRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if len(ref) >= 3 && strings.EqualFold(ref[:3], "rfd") {
+		ref = ref[3:]
+	}
+	ref = strings.TrimLeft(ref, " -#")
+
+	if ref != "" && len(ref) < 4 {
+		ref = fmt.Sprintf("%04s", ref)
+	}
+
+	return ref
+}
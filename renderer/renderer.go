@@ -13,6 +13,7 @@ import (
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 	"go.abhg.dev/goldmark/anchor"
 	"go.abhg.dev/goldmark/mermaid"
 )
@@ -40,6 +41,7 @@ func init() {
 		),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(util.Prioritized(&rfdFileLinks{}, 100)),
 		),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
@@ -58,6 +60,10 @@ func RenderRFD(rfdNum string, f io.Reader) (*models.RFD, error) {
 		Discussion string          `yaml:"discussion"`
 		Tags       []string        `yaml:"tags"`
 		Public     bool            `yaml:"public"`
+		Visibility []string        `yaml:"visibility"`
+		Supersedes []string        `yaml:"supersedes"`
+		DependsOn  []string        `yaml:"depends_on"`
+		Related    []string        `yaml:"related"`
 	}
 
 	var meta yamlMeta
@@ -67,8 +73,11 @@ func RenderRFD(rfdNum string, f io.Reader) (*models.RFD, error) {
 		return nil, err
 	}
 
+	ctx := parser.NewContext()
+	ctx.Set(rfdNumKey, rfdNum)
+
 	var buf bytes.Buffer
-	if err := md.Convert(body, &buf); err != nil {
+	if err := md.Convert(body, &buf, parser.WithContext(ctx)); err != nil {
 		return nil, err
 	}
 
@@ -81,6 +90,7 @@ func RenderRFD(rfdNum string, f io.Reader) (*models.RFD, error) {
 		Discussion: meta.Discussion,
 		Tags:       meta.Tags,
 		Public:     meta.Public,
+		Visibility: meta.Visibility,
 	}
 	rfd.ContentMD = string(body)
 	rfd.Content = string(buf.Bytes())
@@ -88,5 +98,11 @@ func RenderRFD(rfdNum string, f io.Reader) (*models.RFD, error) {
 	// Store the author strings temporarily for core to process
 	rfd.AuthorStrings = meta.Authors
 
+	rfd.Relations = parseRelations(rfdNum, map[models.RFDRelationKind][]string{
+		models.Supersedes: meta.Supersedes,
+		models.DependsOn:  meta.DependsOn,
+		models.Related:    meta.Related,
+	})
+
 	return &rfd, nil
 }
@@ -1,8 +1,11 @@
 package renderer
 
 import (
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
 )
 
 func TestRenderRFDWithDiagrams(t *testing.T) {
@@ -127,4 +130,74 @@ this is invalid d2 syntax $$
 	if !strings.Contains(rfd.Content, "d2-error") {
 		t.Error("Expected d2-error class for invalid syntax")
 	}
-}
\ No newline at end of file
+}
+func TestRenderRFDRewritesFileLinks(t *testing.T) {
+	content := `---
+title: Test RFD
+---
+
+![flow](diagram.png)
+![nested](./images/my%20flow.svg "Flow")
+[spec](spec.pdf#page=2)
+[other rfd](../0002/README.md)
+[notes](notes.md)
+[outside](../secret.png)
+[site](https://example.com/a.png)
+[root](/0002)
+[anchor](#summary)
+`
+
+	rfd, err := RenderRFD("0001", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to render RFD: %v", err)
+	}
+
+	for _, want := range []string{
+		`src="/0001/files/diagram.png"`,
+		`src="/0001/files/images/my%20flow.svg"`,
+		`href="/0001/files/spec.pdf#page=2"`,
+		`href="../0002/README.md"`,
+		`href="notes.md"`,
+		`href="../secret.png"`,
+		`href="https://example.com/a.png"`,
+		`href="/0002"`,
+		`href="#summary"`,
+	} {
+		if !strings.Contains(rfd.Content, want) {
+			t.Errorf("Expected %s in:\n%s", want, rfd.Content)
+		}
+	}
+
+	// The markdown is stored as written
+	if !strings.Contains(rfd.ContentMD, "![flow](diagram.png)") {
+		t.Errorf("Expected ContentMD to be untouched, got %s", rfd.ContentMD)
+	}
+}
+
+func TestRenderRFDRelations(t *testing.T) {
+	content := `---
+title: Test RFD
+supersedes: [7, "RFD 12"]
+depends_on: ["0003", 3]
+related: ["rfd-0042", ""]
+---
+
+Body
+`
+
+	rfd, err := RenderRFD("0050", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to render RFD: %v", err)
+	}
+
+	want := []models.RFDRelation{
+		{RFDID: "0050", Kind: models.DependsOn, TargetID: "0003"},
+		{RFDID: "0050", Kind: models.Related, TargetID: "0042"},
+		{RFDID: "0050", Kind: models.Supersedes, TargetID: "0007"},
+		{RFDID: "0050", Kind: models.Supersedes, TargetID: "0012"},
+	}
+
+	if !reflect.DeepEqual(rfd.Relations, want) {
+		t.Errorf("Expected relations %+v, got %+v", want, rfd.Relations)
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,24 +9,19 @@ import (
 
 	"github.com/geekgonecrazy/rfd-tool/config"
 	"github.com/geekgonecrazy/rfd-tool/core"
+	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/gin-gonic/gin"
 )
 
+// getSessionFromCookieOrHeader reads the _sess JWT from its cookie, or from an
+// Authorization: Bearer header when there's no cookie
 func getSessionFromCookieOrHeader(c *gin.Context) {
-	tokenString, err := c.Cookie("_sess")
-	if err != nil {
-		c.Next()
-		return
-	}
+	fromCookie := true
 
-	if tokenString == "" {
-		tokenString = c.GetHeader("Authorization")
-		if err != nil {
-			log.Println(err)
-
-			c.Next()
-			return
-		}
+	tokenString, err := c.Cookie("_sess")
+	if err != nil || tokenString == "" {
+		fromCookie = false
+		tokenString = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 	}
 
 	if tokenString == "" {
@@ -49,6 +45,14 @@ func getSessionFromCookieOrHeader(c *gin.Context) {
 	if session.User.LoggedIn {
 		c.Set("loggedIn", true)
 		c.Set("userEmail", session.User.Email)
+		c.Set("userName", session.User.Name)
+		c.Set("userRole", string(config.Config.Roles.RoleForGroups(session.User.Groups)))
+		c.Set("viewer", models.Viewer{LoggedIn: true, Email: session.User.Email, Groups: session.User.Groups})
+
+		// Browsers send the cookie along with requests other sites make them send, so
+		// writes authenticated by it have to prove they came from one of our pages
+		c.Set("sessionFromCookie", fromCookie)
+		c.Set("csrfToken", core.CSRFToken(tokenString))
 	}
 
 	c.Next()
@@ -77,32 +81,138 @@ func requireSession(c *gin.Context) {
 	c.Next()
 }
 
-func requireAPISecret(c *gin.Context) {
+// requireRole only lets signed in users with at least role through, use after requireSession
+func requireRole(role models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.Role(c.GetString("userRole")).Allows(role) {
+			if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "error": fmt.Sprintf("requires the %s role", role)})
+				return
+			}
+
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireAPIAuth lets requests with a personal API token in the api-token header through,
+// apiSecret when legacyApiSecret is set, or a signed in session
+func requireAPIAuth(c *gin.Context) {
 	apiToken := c.GetHeader("api-token")
 
-	if config.Config.APISecret == "" {
+	if apiToken == "" {
+		requireAPISession(c)
+		return
+	}
+
+	if config.Config.LegacyAPISecret && subtle.ConstantTimeCompare([]byte(apiToken), []byte(config.Config.APISecret)) == 1 {
+		// The secret isn't tied to a user, so it can do and see everything
+		c.Set("viewer", models.Viewer{All: true})
+		c.Set("apiScopes", models.APITokenScopes{models.ScopeAdmin})
+		c.Next()
+		return
+	}
+
+	token, err := core.AuthenticateAPIToken(apiToken)
+	if err != nil {
+		log.Printf("Error checking API token: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if token == nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
-	if config.Config.APISecret != apiToken {
+	// Everything done with the token is attributed to its owner, and it sees what they would
+	c.Set("userEmail", token.Owner)
+	c.Set("viewer", models.Viewer{LoggedIn: true, Email: token.Owner, Groups: token.OwnerGroups})
+	c.Set("apiScopes", core.APITokenScopes(token))
+
+	c.Next()
+}
+
+// requireAPISession lets signed in users use the API with what their role allows. Writes
+// authenticated by the session cookie need the session's CSRF token in the X-CSRF-Token header.
+func requireAPISession(c *gin.Context) {
+	if !c.GetBool("loggedIn") {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
+	if c.GetBool("sessionFromCookie") && !isSafeMethod(c.Request.Method) {
+		if !validCSRFToken(c, c.GetHeader("X-CSRF-Token")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "error": "missing or invalid CSRF token"})
+			return
+		}
+	}
+
+	c.Set("apiScopes", models.ScopesForRole(models.Role(c.GetString("userRole"))))
+
+	c.Next()
+}
+
+// requireCSRF makes form posts authenticated by the session cookie send the session's CSRF
+// token, in the _csrf field or the X-CSRF-Token header. Use after requireSession.
+func requireCSRF(c *gin.Context) {
+	if !c.GetBool("sessionFromCookie") {
+		c.Next()
+		return
+	}
+
+	csrfToken := c.GetHeader("X-CSRF-Token")
+	if csrfToken == "" {
+		csrfToken = c.PostForm("_csrf")
+	}
+
+	if !validCSRFToken(c, csrfToken) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
 	c.Next()
 }
 
-// requirePublicOrSession allows access if the RFD is public OR the user is logged in.
-// For RFD detail pages, checks if the specific RFD is public.
+// validCSRFToken reports whether csrfToken is the one for the request's session
+func validCSRFToken(c *gin.Context, csrfToken string) bool {
+	expected := c.GetString("csrfToken")
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(csrfToken)) == 1
+}
+
+// isSafeMethod reports whether requests with method only read
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// requireScope only lets API requests whose token or session has scope through, use after requireAPIAuth
+func requireScope(scope models.APITokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("apiScopes")
+		scopes, _ := value.(models.APITokenScopes)
+
+		if !scopes.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "error": fmt.Sprintf("requires the %s scope", scope)})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requirePublicOrSession allows access if the RFD is public OR the user is logged in
+// and allowed to see it. For RFD detail pages, checks if the specific RFD is public.
 // Sets "isPublicView" in context to indicate if viewing as public (not logged in).
 func requirePublicOrSession(c *gin.Context) {
 	loggedIn := c.GetBool("loggedIn")
 
-	// If logged in, allow access
+	// If logged in, allow access to anything outside of the groups it's restricted to
 	if loggedIn {
 		c.Set("isPublicView", false)
-		c.Next()
+		requireVisibleRFD(c)
 		return
 	}
 
@@ -133,6 +243,33 @@ func requirePublicOrSession(c *gin.Context) {
 	c.Next()
 }
 
+// requireVisibleRFD 404s RFDs restricted to groups the signed in user isn't in,
+// as if they didn't exist. Use after requireSession.
+func requireVisibleRFD(c *gin.Context) {
+	id := c.Param("id")
+
+	visible, err := core.CanViewRFD(id, requestViewer(c))
+	if err != nil {
+		log.Printf("Error checking if RFD %s is visible: %v", id, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if !visible {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Next()
+}
+
+// requestViewer is who the request is showing RFDs to, set by getSessionFromCookieOrHeader or requireAPIAuth
+func requestViewer(c *gin.Context) models.Viewer {
+	value, _ := c.Get("viewer")
+	viewer, _ := value.(models.Viewer)
+	return viewer
+}
+
 // optionalPublicOrSession is for pages that work differently based on auth state.
 // Sets "isPublicView" to true if not logged in.
 func optionalPublicOrSession(c *gin.Context) {
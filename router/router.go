@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/geekgonecrazy/rfd-tool/controllers"
+	"github.com/geekgonecrazy/rfd-tool/models"
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
 )
@@ -19,38 +20,76 @@ func Run() error {
 	router.GET("/oidc/login", controllers.OIDCAuthorizationURLHandler)
 	router.GET("/oidc/callback", controllers.OIDCCallbackHandler)
 
+	// GitHub push events, verified with github.webhookSecret
+	router.POST("/hooks/github", controllers.GithubWebhookHandler)
+
 	router.Use(getSessionFromCookieOrHeader)
 
 	api := router.Group("/api/v1")
 	{
-		// ALL API endpoints use API token authentication
-		api.Use(requireAPISecret)
+		// ALL API endpoints need an API token or a signed in session
+		api.Use(requireAPIAuth)
+
+		readRFDs := requireScope(models.ScopeRFDsRead)
+		writeRFDs := requireScope(models.ScopeRFDsWrite)
+		admin := requireScope(models.ScopeAdmin)
+
+		api.POST("/rfds/:id", writeRFDs, controllers.CreateOrUpdateRFDHandler)
+		api.GET("/rfds", readRFDs, controllers.GetRFDsHandler)
+		api.POST("/rfds", writeRFDs, controllers.CreateRFDHandler)
+		api.GET("/rfds/:id", readRFDs, controllers.GetRFDHandler)
+		api.GET("/rfds/:id/revisions", readRFDs, controllers.GetRFDRevisionsHandler)
+		api.GET("/rfds/:id/relations", readRFDs, controllers.GetRFDRelationsHandler)
+		api.GET("/rfds/:id/files", readRFDs, controllers.GetRFDFilesHandler)
+		api.PUT("/rfds/:id/files/*path", writeRFDs, controllers.UploadRFDFileHandler)
+		api.POST("/rfds/:id/state", writeRFDs, controllers.ChangeRFDStateHandler)
+
+		api.GET("/templates", readRFDs, controllers.GetTemplatesHandler)
+
+		api.GET("/search", readRFDs, controllers.SearchHandler)
 
-		api.POST("/rfds/:id", controllers.CreateOrUpdateRFDHandler)
-		api.GET("/rfds", controllers.GetRFDsHandler)
-		api.POST("/rfds", controllers.CreateRFDHandler)
-		api.GET("/rfds/:id", controllers.GetRFDHandler)
+		api.GET("/tags", readRFDs, controllers.GetTagsHandler)
+		api.GET("/tags/:tag/rfds", readRFDs, controllers.GetRFDsForTagHandler)
 
-		api.GET("/tags", controllers.GetTagsHandler)
-		api.GET("/tags/:tag/rfds", controllers.GetRFDsForTagHandler)
+		api.GET("/authors", readRFDs, controllers.GetAuthorsHandler)
+		api.GET("/authors/:id", readRFDs, controllers.GetAuthorHandler)
+		api.GET("/authors/:id/rfds", readRFDs, controllers.GetAuthorRFDsHandler)
 
-		api.GET("/authors", controllers.GetAuthorsHandler)
-		api.GET("/authors/:id", controllers.GetAuthorHandler)
-		api.GET("/authors/:id/rfds", controllers.GetAuthorRFDsHandler)
+		api.GET("/sync", readRFDs, controllers.GetSyncStatusHandler)
+		api.POST("/sync", admin, controllers.TriggerSyncHandler)
 	}
 
 	// Server Side Rendered Pages
 	// Public-aware pages: show public RFDs when not logged in, all RFDs when logged in
 	router.GET("/tag/:tag", optionalPublicOrSession, controllers.TagListPageHandler)
 	router.GET("/author/:id", optionalPublicOrSession, controllers.AuthorListPageHandler)
+	router.GET("/search", optionalPublicOrSession, controllers.SearchPageHandler)
 
 	// RFD detail page: public RFDs accessible without login
 	router.GET("/:id", requirePublicOrSession, controllers.RFDPageHandler)
+	router.GET("/:id/files/*path", requirePublicOrSession, controllers.RFDFileHandler)
 
 	// These always require login
-	router.GET("/create", requireSession, controllers.RFDCreatePageHandler)
-	router.GET("/created", requireSession, controllers.RFDCreatedPageHandler)
-	router.POST("/created", requireSession, controllers.RFDCreatedPageHandler)
+	router.GET("/:id/diff", requireSession, requireVisibleRFD, controllers.RFDDiffPageHandler)
+
+	// Changing RFDs needs the author role. Every form post needs the session's CSRF token.
+	requireAuthor := requireRole(models.RoleAuthor)
+	router.POST("/:id/state", requireSession, requireCSRF, requireVisibleRFD, requireAuthor, controllers.RFDStatePageHandler)
+	router.GET("/:id/edit", requireSession, requireVisibleRFD, requireAuthor, controllers.RFDEditPageHandler)
+	router.POST("/:id/edit", requireSession, requireCSRF, requireVisibleRFD, requireAuthor, controllers.RFDEditSaveHandler)
+	router.POST("/:id/preview", requireSession, requireCSRF, requireVisibleRFD, requireAuthor, controllers.RFDPreviewHandler)
+	router.GET("/create", requireSession, requireAuthor, controllers.RFDCreatePageHandler)
+	router.POST("/create", requireSession, requireCSRF, requireAuthor, controllers.RFDCreateSaveHandler)
+	router.GET("/created", requireSession, requireAuthor, controllers.RFDCreatedPageHandler)
+
+	requireAdmin := requireRole(models.RoleAdmin)
+	router.GET("/admin/repo-writes", requireSession, requireAdmin, controllers.RepoWritesPageHandler)
+	router.POST("/admin/repo-writes/:id/retry", requireSession, requireCSRF, requireAdmin, controllers.RepoWriteRetryHandler)
+
+	// Personal API tokens
+	router.GET("/tokens", requireSession, controllers.APITokensPageHandler)
+	router.POST("/tokens", requireSession, requireCSRF, controllers.APITokenCreateHandler)
+	router.POST("/tokens/:id/revoke", requireSession, requireCSRF, controllers.APITokenRevokeHandler)
 
 	router.GET("/login", controllers.LoginPageHandler)
 	router.GET("/logout", controllers.LogoutHandler)
@@ -76,7 +76,14 @@ oidc:
   authUrl: http://localhost:5556/dex/auth
   tokenUrl: http://localhost:5556/dex/token
 
+roles:
+  default: viewer
+  groups:
+    engineering@acme.com: author
+    engineering-leads@acme.com: admin
+
 apiSecret: dev-api-secret-change-in-production
+legacyApiSecret: true
 
 jwt:
   publicKey: |
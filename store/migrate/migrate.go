@@ -0,0 +1,272 @@
+// Package migrate applies numbered SQL migrations embedded in the binary.
+//
+// Migrations are files named NNNN_description.sql. Each one runs in its own
+// transaction and is recorded in the schema_migrations table.
+//
+// A migration starting with a "-- only if: <query>" line only runs when the
+// query returns true, for changes SQL can't make conditionally, such as adding
+// a column sqlite databases from before migrations may already have.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// conditionPrefix starts the first line of a migration that only runs when its query returns true
+const conditionPrefix = "-- only if:"
+
+// Migration is a single numbered up migration
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+
+	// Condition is a query returning whether the migration needs to run, empty to always run it
+	Condition string
+}
+
+// Runner applies migrations to a database
+type Runner struct {
+	DB         *sql.DB
+	Migrations []Migration
+
+	// Placeholder returns the bind parameter for the nth (1 based) argument
+	Placeholder func(n int) string
+
+	// Lock is called at the start of each migration transaction, so that
+	// multiple servers starting at once don't apply the same migration twice
+	Lock func(tx *sql.Tx) error
+}
+
+// Load reads the migrations in dir, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := map[int]string{}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %q, expected NNNN_description.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration := Migration{Version: version, Name: matches[2], SQL: string(contents)}
+
+		firstLine, _, _ := strings.Cut(migration.SQL, "\n")
+		if strings.HasPrefix(firstLine, conditionPrefix) {
+			migration.Condition = strings.TrimSpace(strings.TrimPrefix(firstLine, conditionPrefix))
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// LatestVersion is the newest migration this binary knows about
+func (r *Runner) LatestVersion() int {
+	if len(r.Migrations) == 0 {
+		return 0
+	}
+
+	return r.Migrations[len(r.Migrations)-1].Version
+}
+
+func (r *Runner) ensureTable() error {
+	_, err := r.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+
+	return err
+}
+
+func (r *Runner) applied() (map[int]time.Time, error) {
+	rows, err := r.DB.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Check refuses to continue if the database has migrations this binary doesn't know about
+func (r *Runner) Check() error {
+	if err := r.ensureTable(); err != nil {
+		return err
+	}
+
+	var current sql.NullInt64
+	if err := r.DB.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	if current.Valid && int(current.Int64) > r.LatestVersion() {
+		return fmt.Errorf("database schema is at version %d but this binary only knows up to %d, refusing to start (upgrade rfd-server)", current.Int64, r.LatestVersion())
+	}
+
+	return nil
+}
+
+// Up applies every pending migration in order
+func (r *Runner) Up() error {
+	if err := r.Check(); err != nil {
+		return err
+	}
+
+	applied, err := r.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range r.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := r.apply(migration); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) apply(migration Migration) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if r.Lock != nil {
+		if err := r.Lock(tx); err != nil {
+			return err
+		}
+	}
+
+	// Another server may have applied it while we waited for the lock
+	var count int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM schema_migrations WHERE version = %s`, r.Placeholder(1))
+	if err := tx.QueryRow(query, migration.Version).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	run := true
+	if migration.Condition != "" {
+		if err := tx.QueryRow(migration.Condition).Scan(&run); err != nil {
+			return fmt.Errorf("checking condition: %w", err)
+		}
+	}
+
+	if run {
+		log.Printf("Applying migration %04d_%s", migration.Version, migration.Name)
+
+		if _, err := tx.Exec(migration.SQL); err != nil {
+			return err
+		}
+	} else {
+		// Still recorded, so it isn't checked again
+		log.Printf("Skipping migration %04d_%s, not needed", migration.Version, migration.Name)
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)`, r.Placeholder(1), r.Placeholder(2), r.Placeholder(3))
+	if _, err := tx.Exec(insert, migration.Version, migration.Name, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Status lists every known migration and whether it has been applied
+func (r *Runner) Status() ([]models.MigrationStatus, error) {
+	if err := r.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []models.MigrationStatus{}
+	for _, migration := range r.Migrations {
+		status := models.MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	// Anything left was applied by a newer binary
+	for version, appliedAt := range applied {
+		appliedAt := appliedAt
+		statuses = append(statuses, models.MigrationStatus{Version: version, Name: "unknown", Applied: true, AppliedAt: &appliedAt})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
@@ -0,0 +1,160 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestRunner(t *testing.T, files fstest.MapFS) *Runner {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	return &Runner{
+		DB:          db,
+		Migrations:  migrations,
+		Placeholder: func(n int) string { return "?" },
+	}
+}
+
+func TestUpAppliesPendingInOrder(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0002_add_col.sql": {Data: []byte(`ALTER TABLE things ADD COLUMN color TEXT NOT NULL DEFAULT '';`)},
+		"migrations/0001_things.sql":  {Data: []byte(`CREATE TABLE things (id TEXT PRIMARY KEY);`)},
+	}
+
+	runner := newTestRunner(t, files)
+
+	if runner.LatestVersion() != 2 {
+		t.Fatalf("Expected latest version 2, got %d", runner.LatestVersion())
+	}
+
+	if err := runner.Up(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// Running again is a no-op
+	if err := runner.Up(); err != nil {
+		t.Fatalf("Failed to re-run migrations: %v", err)
+	}
+
+	if _, err := runner.DB.Exec(`INSERT INTO things (id, color) VALUES ('a', 'blue')`); err != nil {
+		t.Fatalf("Expected migrated schema: %v", err)
+	}
+
+	statuses, err := runner.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+
+	if len(statuses) != 2 || !statuses[0].Applied || !statuses[1].Applied || statuses[1].Name != "add_col" {
+		t.Errorf("Unexpected status: %+v", statuses)
+	}
+}
+
+func TestCheckRefusesNewerSchema(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0001_things.sql": {Data: []byte(`CREATE TABLE things (id TEXT PRIMARY KEY);`)},
+	}
+
+	runner := newTestRunner(t, files)
+
+	if err := runner.Up(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	if _, err := runner.DB.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (7, 'future', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("Failed to fake newer migration: %v", err)
+	}
+
+	err := runner.Check()
+	if err == nil || !strings.Contains(err.Error(), "refusing to start") {
+		t.Errorf("Expected refusal for newer schema, got %v", err)
+	}
+
+	if err := runner.Up(); err == nil {
+		t.Error("Expected Up to refuse newer schema")
+	}
+}
+
+func TestLoadRejectsBadNames(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/add_things.sql": {Data: []byte(`SELECT 1;`)},
+	}
+
+	if _, err := Load(files, "migrations"); err == nil {
+		t.Error("Expected error for migration without version")
+	}
+
+	files = fstest.MapFS{
+		"migrations/0001_a.sql": {Data: []byte(`SELECT 1;`)},
+		"migrations/1_b.sql":    {Data: []byte(`SELECT 1;`)},
+	}
+
+	if _, err := Load(files, "migrations"); err == nil {
+		t.Error("Expected error for duplicate versions")
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0001_things.sql": {Data: []byte(`CREATE TABLE things (id TEXT PRIMARY KEY); INSERT INTO nope VALUES (1);`)},
+	}
+
+	runner := newTestRunner(t, files)
+
+	if err := runner.Up(); err == nil {
+		t.Fatal("Expected migration to fail")
+	}
+
+	statuses, err := runner.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+
+	if statuses[0].Applied {
+		t.Error("Expected failed migration to not be recorded")
+	}
+}
+
+func TestConditionalMigration(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0001_things.sql": {Data: []byte(`CREATE TABLE IF NOT EXISTS things (id TEXT PRIMARY KEY, color TEXT);`)},
+		"migrations/0002_color.sql": {Data: []byte("-- only if: SELECT COUNT(*) = 0 FROM pragma_table_info('things') WHERE name = 'color'\n" +
+			"ALTER TABLE things ADD COLUMN color TEXT;")},
+	}
+
+	runner := newTestRunner(t, files)
+
+	if runner.Migrations[1].Condition == "" {
+		t.Fatal("Expected the condition to be read from the first line")
+	}
+
+	// The column is already there, running the ALTER would fail
+	if err := runner.Up(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	statuses, err := runner.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+
+	if !statuses[1].Applied {
+		t.Error("Expected the skipped migration to be recorded")
+	}
+}
@@ -0,0 +1,137 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const apiTokenColumns = `id, name, owner, owner_groups, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at, groups_checked_at`
+
+// CreateAPIToken stores a new API token
+func (s *postgresStore) CreateAPIToken(token *models.APIToken) error {
+	token.CreatedAt = time.Now()
+
+	groupsJSON, err := json.Marshal(token.OwnerGroups)
+	if err != nil {
+		return err
+	}
+
+	scopesJSON, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return s.db.QueryRow(`
+		INSERT INTO api_tokens (name, owner, owner_groups, prefix, hash, scopes, expires_at, created_at, groups_checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, token.Name, token.Owner, string(groupsJSON), token.Prefix, token.Hash, string(scopesJSON), token.ExpiresAt, token.CreatedAt, token.GroupsCheckedAt).Scan(&token.ID)
+}
+
+// GetAPIToken returns a token, nil if there's none with id
+func (s *postgresStore) GetAPIToken(id int64) (*models.APIToken, error) {
+	return s.getAPIToken(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = $1`, id)
+}
+
+// GetAPITokenByHash returns the token with hash, nil if there's none
+func (s *postgresStore) GetAPITokenByHash(hash string) (*models.APIToken, error) {
+	return s.getAPIToken(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE hash = $1`, hash)
+}
+
+func (s *postgresStore) getAPIToken(query string, arg interface{}) (*models.APIToken, error) {
+	rows, err := s.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := scanAPITokens(rows)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+
+	return &tokens[0], nil
+}
+
+// GetAPITokens returns owner's tokens, newest first
+func (s *postgresStore) GetAPITokens(owner string) ([]models.APIToken, error) {
+	rows, err := s.db.Query(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE owner = $1 ORDER BY id DESC`, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAPITokens(rows)
+}
+
+// RevokeAPIToken stops a token from being used, keeping it around for the record
+func (s *postgresStore) RevokeAPIToken(id int64, revokedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, revokedAt, id)
+	return err
+}
+
+// MarkAPITokenGroupsChecked records that the token owner's groups still cover the token's
+func (s *postgresStore) MarkAPITokenGroupsChecked(id int64, checkedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET groups_checked_at = $1 WHERE id = $2`, checkedAt, id)
+	return err
+}
+
+// TouchAPIToken records when a token was last used
+func (s *postgresStore) TouchAPIToken(id int64, usedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	return err
+}
+
+func scanAPITokens(rows *sql.Rows) ([]models.APIToken, error) {
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		var token models.APIToken
+		var groupsJSON, scopesJSON []byte
+		var expiresAt, lastUsedAt, revokedAt, groupsCheckedAt sql.NullTime
+
+		if err := rows.Scan(
+			&token.ID,
+			&token.Name,
+			&token.Owner,
+			&groupsJSON,
+			&token.Prefix,
+			&token.Hash,
+			&scopesJSON,
+			&expiresAt,
+			&lastUsedAt,
+			&revokedAt,
+			&token.CreatedAt,
+			&groupsCheckedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(groupsJSON, &token.OwnerGroups); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(scopesJSON, &token.Scopes); err != nil {
+			return nil, err
+		}
+
+		token.ExpiresAt = timePtr(expiresAt)
+		token.LastUsedAt = timePtr(lastUsedAt)
+		token.RevokedAt = timePtr(revokedAt)
+		token.GroupsCheckedAt = groupsCheckedAt.Time
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
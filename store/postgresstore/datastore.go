@@ -0,0 +1,104 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/store/migrate"
+	_ "github.com/lib/pq"
+)
+
+// Arbitrary key for pg_advisory_xact_lock so replicas don't race applying migrations
+const migrationLockID = 7216350
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// scanner interface for sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func New() (*postgresStore, error) {
+	if config.Config.DatabaseDSN == "" {
+		return nil, errors.New("databaseDSN is required for the postgres store")
+	}
+
+	db, err := sql.Open("postgres", config.Config.DatabaseDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	store := &postgresStore{db: db}
+
+	runner, err := store.migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse to touch a database migrated by a newer binary
+	if err := runner.Check(); err != nil {
+		return nil, err
+	}
+
+	log.Println("Postgres store initialized")
+	return store, nil
+}
+
+func (s *postgresStore) migrator() (*migrate.Runner, error) {
+	migrations, err := migrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrate.Runner{
+		DB:          s.db,
+		Migrations:  migrations,
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		Lock: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID)
+			return err
+		},
+	}, nil
+}
+
+// Migrate applies any pending schema migrations
+func (s *postgresStore) Migrate() error {
+	runner, err := s.migrator()
+	if err != nil {
+		return err
+	}
+
+	return runner.Up()
+}
+
+// MigrationStatus lists the known migrations and whether they've been applied
+func (s *postgresStore) MigrationStatus() ([]models.MigrationStatus, error) {
+	runner, err := s.migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.Status()
+}
+
+func (s *postgresStore) CheckDb() error {
+	return s.db.Ping()
+}
+
+func (s *postgresStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,80 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+)
+
+// newTestStore opens a freshly migrated store in its own schema of the database at
+// POSTGRES_TEST_DSN, skipping the test if it isn't set
+func newTestStore(t *testing.T) *postgresStore {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("rfd_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	configYAML := fmt.Sprintf("dataPath: %s/\nstore: postgres\ndatabaseDSN: %q\n", dir, withSearchPath(t, dsn, schema))
+	if err := os.WriteFile(configFile, []byte(configYAML), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := config.Load(configFile); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	store, err := New()
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return store
+}
+
+// withSearchPath points every connection made with dsn at schema
+func withSearchPath(t *testing.T, dsn string, schema string) string {
+	t.Helper()
+
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("Failed to parse POSTGRES_TEST_DSN: %v", err)
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,53 @@
+package postgresstore
+
+import (
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateRFDMerge records an RFD's branch being merged
+func (s *postgresStore) CreateRFDMerge(merge *models.RFDMerge) error {
+	merge.CreatedAt = time.Now()
+
+	return s.db.QueryRow(`
+		INSERT INTO rfd_merges (rfd_id, branch, commit_sha, fast_forward, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, merge.RFDID, merge.Branch, merge.CommitSHA, merge.FastForward, merge.Actor, merge.CreatedAt).Scan(&merge.ID)
+}
+
+// GetRFDMerges returns every merge of an RFD's branch, newest first
+func (s *postgresStore) GetRFDMerges(rfdID string) ([]models.RFDMerge, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, branch, commit_sha, fast_forward, actor, created_at
+		FROM rfd_merges
+		WHERE rfd_id = $1
+		ORDER BY id DESC
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := []models.RFDMerge{}
+	for rows.Next() {
+		var merge models.RFDMerge
+
+		if err := rows.Scan(
+			&merge.ID,
+			&merge.RFDID,
+			&merge.Branch,
+			&merge.CommitSHA,
+			&merge.FastForward,
+			&merge.Actor,
+			&merge.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		merges = append(merges, merge)
+	}
+
+	return merges, rows.Err()
+}
@@ -0,0 +1,96 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (s *postgresStore) GetNextRFDID() (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'nextRFD'`).Scan(&value)
+	if err == sql.ErrNoRows {
+		// Initialize to 0001 if not set
+		return "0001", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	nextID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%04d", nextID), nil
+}
+
+// ReserveNextRFDID hands out the next RFD number and moves nextRFD past it in a
+// single statement, so two creates, even on different replicas, can never be given the same number
+func (s *postgresStore) ReserveNextRFDID() (string, error) {
+	var next int64
+	err := s.db.QueryRow(`
+		INSERT INTO meta (key, value) VALUES ('nextRFD', '2')
+		ON CONFLICT (key) DO UPDATE SET value = (meta.value::BIGINT + 1)::TEXT
+		RETURNING value::BIGINT
+	`).Scan(&next)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%04d", next-1), nil
+}
+
+// RaiseNextRFDID bumps nextRFD to id+1 unless it's already past it
+func (s *postgresStore) RaiseNextRFDID(rfdID string) error {
+	return raiseNextRFDID(s.db, rfdID)
+}
+
+// raiseNextRFDID bumps nextRFD to id+1 unless it's already past it. Done in a
+// single statement so concurrent imports on other replicas can't lower it.
+func raiseNextRFDID(e execer, rfdID string) error {
+	id, err := strconv.ParseInt(rfdID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(`
+		INSERT INTO meta (key, value) VALUES ('nextRFD', $1)
+		ON CONFLICT (key) DO UPDATE
+		SET value = GREATEST(meta.value::BIGINT, EXCLUDED.value::BIGINT)::TEXT
+	`, fmt.Sprintf("%d", id+1))
+
+	return err
+}
+
+func (s *postgresStore) EnsureUpdateLatestRFDID() error {
+	// Find the highest RFD ID in the database
+	var maxID sql.NullInt64
+	err := s.db.QueryRow(`SELECT MAX(CAST(id AS BIGINT)) FROM rfds WHERE id ~ '^[0-9]+$'`).Scan(&maxID)
+	if err != nil {
+		return err
+	}
+
+	if !maxID.Valid {
+		// No RFDs yet, start at 1 unless numbers were already handed out
+		maxID.Int64 = 0
+	}
+
+	// Never lower it, another replica may have reserved numbers for RFDs that aren't stored yet
+	if err := raiseNextRFDID(s.db, fmt.Sprintf("%d", maxID.Int64)); err != nil {
+		return err
+	}
+
+	next, err := s.GetNextRFDID()
+	if err == nil {
+		log.Println("Next RFD ID is", next)
+	}
+
+	return err
+}
@@ -0,0 +1,72 @@
+package postgresstore
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestReserveNextRFDIDNeverRepeats(t *testing.T) {
+	store := newTestStore(t)
+
+	reserved := make(chan string, 20)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(reserved); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id, err := store.ReserveNextRFDID()
+			if err != nil {
+				t.Errorf("Failed to reserve ID: %v", err)
+				return
+			}
+			reserved <- id
+		}()
+	}
+	wg.Wait()
+	close(reserved)
+
+	seen := map[string]bool{}
+	for id := range reserved {
+		if seen[id] {
+			t.Errorf("ID %s was reserved twice", id)
+		}
+		seen[id] = true
+	}
+
+	if !seen["0001"] || !seen["0020"] {
+		t.Errorf("Expected IDs 0001 through 0020, got %v", seen)
+	}
+}
+
+func TestNextRFDIDIsNeverLowered(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.ImportRFD(&models.RFD{ID: "0003", RFDMeta: models.RFDMeta{Title: "Third", State: models.Ideation}}); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	if err := store.RaiseNextRFDID("0009"); err != nil {
+		t.Fatalf("Failed to raise next ID: %v", err)
+	}
+
+	// Startup recalculates from stored RFDs, the raised number must survive it
+	if err := store.EnsureUpdateLatestRFDID(); err != nil {
+		t.Fatalf("Failed to update next ID: %v", err)
+	}
+
+	if err := store.RaiseNextRFDID("0004"); err != nil {
+		t.Fatalf("Failed to raise next ID: %v", err)
+	}
+
+	id, err := store.ReserveNextRFDID()
+	if err != nil {
+		t.Fatalf("Failed to reserve ID: %v", err)
+	}
+
+	if id != "0010" {
+		t.Errorf("Expected 0010, got %s", id)
+	}
+}
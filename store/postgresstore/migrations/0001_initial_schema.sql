@@ -0,0 +1,52 @@
+-- Initial schema
+
+-- rfds table (authors are stored in the rfd_authors relationship table)
+CREATE TABLE IF NOT EXISTS rfds (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT '',
+	discussion TEXT NOT NULL DEFAULT '',
+	tags JSONB NOT NULL DEFAULT '[]',
+	public BOOLEAN NOT NULL DEFAULT FALSE,
+	content TEXT NOT NULL DEFAULT '',
+	content_md TEXT NOT NULL DEFAULT '',
+	pr_link TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	name TEXT PRIMARY KEY,
+	rfds JSONB NOT NULL DEFAULT '[]',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS authors (
+	id TEXT PRIMARY KEY,
+	email TEXT NOT NULL DEFAULT '',
+	name TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- many-to-many relationship between rfds and authors
+CREATE TABLE IF NOT EXISTS rfd_authors (
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	author_id TEXT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (rfd_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfds_state ON rfds(state);
+CREATE INDEX IF NOT EXISTS idx_rfds_modified ON rfds(modified_at);
+CREATE INDEX IF NOT EXISTS idx_rfds_tags ON rfds USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_rfd_authors_rfd_id ON rfd_authors(rfd_id);
+CREATE INDEX IF NOT EXISTS idx_rfd_authors_author_id ON rfd_authors(author_id);
+CREATE INDEX IF NOT EXISTS idx_authors_email ON authors(email);
+CREATE INDEX IF NOT EXISTS idx_authors_name ON authors(LOWER(name));
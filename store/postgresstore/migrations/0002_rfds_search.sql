@@ -0,0 +1,8 @@
+-- Full text search over RFD titles and bodies
+ALTER TABLE rfds ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', title), 'A') ||
+		setweight(to_tsvector('english', content_md), 'B')
+	) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_rfds_search ON rfds USING GIN (search_vector);
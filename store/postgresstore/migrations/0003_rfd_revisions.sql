@@ -0,0 +1,30 @@
+-- A snapshot of an RFD every time it changes
+CREATE TABLE IF NOT EXISTS rfd_revisions (
+	id BIGSERIAL PRIMARY KEY,
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	title TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT '',
+	discussion TEXT NOT NULL DEFAULT '',
+	tags JSONB NOT NULL DEFAULT '[]',
+	authors JSONB NOT NULL DEFAULT '[]',
+	public BOOLEAN NOT NULL DEFAULT FALSE,
+	content_md TEXT NOT NULL DEFAULT '',
+	commit_sha TEXT NOT NULL DEFAULT '',
+	actor TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_revisions_rfd_id ON rfd_revisions(rfd_id, id);
+
+-- Seed history with what every existing RFD says today
+INSERT INTO rfd_revisions (rfd_id, title, state, discussion, tags, authors, public, content_md, actor, created_at)
+SELECT
+	r.id, r.title, r.state, r.discussion, r.tags,
+	COALESCE((
+		SELECT jsonb_agg(jsonb_build_object('id', a.id, 'email', a.email, 'name', a.name))
+		FROM rfd_authors ra
+		JOIN authors a ON a.id = ra.author_id
+		WHERE ra.rfd_id = r.id
+	), '[]'::jsonb),
+	r.public, r.content_md, 'migration', r.modified_at
+FROM rfds r;
@@ -0,0 +1,24 @@
+-- Every time an RFD moves between states
+CREATE TABLE IF NOT EXISTS rfd_state_transitions (
+	id BIGSERIAL PRIMARY KEY,
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	from_state TEXT NOT NULL DEFAULT '',
+	to_state TEXT NOT NULL,
+	allowed BOOLEAN NOT NULL DEFAULT TRUE,
+	actor TEXT NOT NULL DEFAULT '',
+	commit_sha TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_state_transitions_rfd_id ON rfd_state_transitions(rfd_id, id);
+
+-- Rebuild what history we have from the revisions
+INSERT INTO rfd_state_transitions (rfd_id, from_state, to_state, actor, commit_sha, created_at)
+SELECT rfd_id, previous_state, state, actor, commit_sha, created_at
+FROM (
+	SELECT id, rfd_id, state, actor, commit_sha, created_at,
+		LAG(state, 1, '') OVER (PARTITION BY rfd_id ORDER BY id) AS previous_state
+	FROM rfd_revisions
+) revisions
+WHERE state != previous_state
+ORDER BY id;
@@ -0,0 +1,12 @@
+-- RFD branches merged into the main branch
+CREATE TABLE IF NOT EXISTS rfd_merges (
+	id BIGSERIAL PRIMARY KEY,
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	branch TEXT NOT NULL,
+	commit_sha TEXT NOT NULL,
+	fast_forward BOOLEAN NOT NULL DEFAULT FALSE,
+	actor TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_merges_rfd_id ON rfd_merges(rfd_id, id);
@@ -0,0 +1,11 @@
+-- Attachments stored alongside an RFD's README.md, served at /:id/files/*path
+CREATE TABLE IF NOT EXISTS rfd_files (
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	path TEXT NOT NULL,
+	content_type TEXT NOT NULL DEFAULT '',
+	size BIGINT NOT NULL DEFAULT 0,
+	sha TEXT NOT NULL,
+	content BYTEA NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (rfd_id, path)
+);
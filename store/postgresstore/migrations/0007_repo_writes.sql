@@ -0,0 +1,15 @@
+-- Changes to the RFD repo waiting to be committed, retried until they land
+CREATE TABLE IF NOT EXISTS repo_writes (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	value TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repo_writes_due ON repo_writes(status, next_attempt_at);
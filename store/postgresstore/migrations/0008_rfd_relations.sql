@@ -0,0 +1,11 @@
+-- Links between RFDs declared in frontmatter (supersedes, depends_on, related).
+-- Only the RFD declaring the link is stored, inverse links are found by target_id.
+-- The target may not exist yet, so it has no foreign key.
+CREATE TABLE IF NOT EXISTS rfd_relations (
+	rfd_id TEXT NOT NULL REFERENCES rfds(id) ON DELETE CASCADE,
+	kind TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	PRIMARY KEY (rfd_id, kind, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_relations_target_id ON rfd_relations(target_id);
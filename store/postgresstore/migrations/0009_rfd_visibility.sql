@@ -0,0 +1,2 @@
+-- Groups an RFD is restricted to, empty for RFDs anyone signed in can read
+ALTER TABLE rfds ADD COLUMN IF NOT EXISTS visibility JSONB NOT NULL DEFAULT '[]';
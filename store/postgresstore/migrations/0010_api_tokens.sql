@@ -0,0 +1,16 @@
+-- Personal API tokens, only a sha256 of the token is kept
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	owner_groups JSONB NOT NULL DEFAULT '[]',
+	prefix TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE,
+	scopes JSONB NOT NULL DEFAULT '[]',
+	expires_at TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_owner ON api_tokens(owner);
@@ -0,0 +1,2 @@
+-- Databases created before pr_link existed were adopted by 0001 without it
+ALTER TABLE rfds ADD COLUMN IF NOT EXISTS pr_link TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- When the owner's groups were last confirmed by them signing in
+ALTER TABLE api_tokens ADD COLUMN groups_checked_at TIMESTAMPTZ;
+
+UPDATE api_tokens SET groups_checked_at = created_at;
@@ -0,0 +1,56 @@
+package postgresstore
+
+import (
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// GetRFDRelations returns every relation declared by the RFD or pointing at it
+func (s *postgresStore) GetRFDRelations(rfdID string) ([]models.RFDRelation, error) {
+	rows, err := s.db.Query(`
+		SELECT rfd_id, kind, target_id
+		FROM rfd_relations
+		WHERE rfd_id = $1 OR target_id = $1
+		ORDER BY rfd_id, kind, target_id
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relations := []models.RFDRelation{}
+	for rows.Next() {
+		var relation models.RFDRelation
+		if err := rows.Scan(&relation.RFDID, &relation.Kind, &relation.TargetID); err != nil {
+			return nil, err
+		}
+
+		relations = append(relations, relation)
+	}
+
+	return relations, rows.Err()
+}
+
+// SetRFDRelations replaces the relations declared by the RFD
+func (s *postgresStore) SetRFDRelations(rfdID string, relations []models.RFDRelation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rfd_relations WHERE rfd_id = $1`, rfdID); err != nil {
+		return err
+	}
+
+	for _, relation := range relations {
+		if _, err := tx.Exec(`
+			INSERT INTO rfd_relations (rfd_id, kind, target_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, rfdID, relation.Kind, relation.TargetID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,162 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const repoWriteColumns = `id, kind, rfd_id, value, status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// CreateRepoWrite queues a repo write. Unfinished writes of the same kind for
+// the RFD are superseded, so an older value can never land after a newer one.
+func (s *postgresStore) CreateRepoWrite(write *models.RepoWrite) error {
+	now := time.Now()
+	write.Status = models.RepoWritePending
+	write.CreatedAt = now
+	write.UpdatedAt = now
+	if write.NextAttemptAt.IsZero() {
+		write.NextAttemptAt = now
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE repo_writes SET status = $1, last_error = 'superseded', updated_at = $2
+		WHERE rfd_id = $3 AND kind = $4 AND status != $1
+	`, models.RepoWriteDone, now, write.RFDID, write.Kind); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO repo_writes (kind, rfd_id, value, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, write.Kind, write.RFDID, write.Value, write.Status, write.Attempts, write.LastError, write.NextAttemptAt, write.CreatedAt, write.UpdatedAt).Scan(&write.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimRepoWrites returns up to limit pending writes due by now, oldest first, and
+// pushes their next attempt out to leaseUntil so no other replica picks them up meanwhile.
+// If the claimer dies the writes become due again once the lease runs out.
+func (s *postgresStore) ClaimRepoWrites(now time.Time, leaseUntil time.Time, limit int) ([]models.RepoWrite, error) {
+	rows, err := s.db.Query(`
+		UPDATE repo_writes SET attempts = attempts + 1, next_attempt_at = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id FROM repo_writes
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+repoWriteColumns, now, leaseUntil, models.RepoWritePending, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := scanRepoWrites(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING doesn't keep the subquery's order
+	sortRepoWrites(writes)
+
+	return writes, nil
+}
+
+// UpdateRepoWrite saves a write's status, error and next attempt. Returns sql.ErrNoRows
+// if the write is already done, such as superseded while it was being run.
+func (s *postgresStore) UpdateRepoWrite(write *models.RepoWrite) error {
+	write.UpdatedAt = time.Now()
+
+	result, err := s.db.Exec(`
+		UPDATE repo_writes SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = $5
+		WHERE id = $6 AND status != $7
+	`, write.Status, write.Attempts, write.LastError, write.NextAttemptAt, write.UpdatedAt, write.ID, models.RepoWriteDone)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetRepoWrite returns a queued write, nil if there's none with id
+func (s *postgresStore) GetRepoWrite(id int64) (*models.RepoWrite, error) {
+	rows, err := s.db.Query(`SELECT `+repoWriteColumns+` FROM repo_writes WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := scanRepoWrites(rows)
+	if err != nil || len(writes) == 0 {
+		return nil, err
+	}
+
+	return &writes[0], nil
+}
+
+// GetRepoWrites returns up to limit writes with status, newest first
+func (s *postgresStore) GetRepoWrites(status models.RepoWriteStatus, limit int) ([]models.RepoWrite, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoWriteColumns+` FROM repo_writes
+		WHERE status = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRepoWrites(rows)
+}
+
+func scanRepoWrites(rows *sql.Rows) ([]models.RepoWrite, error) {
+	defer rows.Close()
+
+	writes := []models.RepoWrite{}
+	for rows.Next() {
+		var write models.RepoWrite
+		if err := rows.Scan(
+			&write.ID,
+			&write.Kind,
+			&write.RFDID,
+			&write.Value,
+			&write.Status,
+			&write.Attempts,
+			&write.LastError,
+			&write.NextAttemptAt,
+			&write.CreatedAt,
+			&write.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		writes = append(writes, write)
+	}
+
+	return writes, rows.Err()
+}
+
+func sortRepoWrites(writes []models.RepoWrite) {
+	sort.Slice(writes, func(i, j int) bool { return writes[i].ID < writes[j].ID })
+}
@@ -0,0 +1,182 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestClaimRepoWrites(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, id := range []string{"0001", "0002"} {
+		if err := store.ImportRFD(&models.RFD{ID: id, RFDMeta: models.RFDMeta{Title: "RFD " + id}}); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	old := &models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: "0001", Value: "https://example.com/old"}
+	newer := &models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: "0001", Value: "https://example.com/new"}
+	later := &models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: "0002", Value: "https://example.com/later", NextAttemptAt: time.Now().Add(time.Hour)}
+
+	for _, write := range []*models.RepoWrite{old, newer, later} {
+		if err := store.CreateRepoWrite(write); err != nil {
+			t.Fatalf("Failed to queue write: %v", err)
+		}
+	}
+
+	now := time.Now()
+	claimed, err := store.ClaimRepoWrites(now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Failed to claim writes: %v", err)
+	}
+
+	// The older link was superseded and the other isn't due yet
+	if len(claimed) != 1 || claimed[0].ID != newer.ID {
+		t.Fatalf("Expected only the newest write to be claimed, got %+v", claimed)
+	}
+
+	if claimed[0].Attempts != 1 {
+		t.Errorf("Expected the claim to count as an attempt, got %d", claimed[0].Attempts)
+	}
+
+	superseded, err := store.GetRepoWrite(old.ID)
+	if err != nil {
+		t.Fatalf("Failed to get write: %v", err)
+	}
+
+	if superseded.Status != models.RepoWriteDone {
+		t.Errorf("Expected the older write to be done, got %s", superseded.Status)
+	}
+
+	// Leased writes aren't handed out again until the lease runs out
+	claimed, err = store.ClaimRepoWrites(now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Failed to claim writes: %v", err)
+	}
+
+	if len(claimed) != 0 {
+		t.Errorf("Expected nothing to claim during the lease, got %+v", claimed)
+	}
+
+	claimed, err = store.ClaimRepoWrites(now.Add(2*time.Hour), now.Add(3*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to claim writes: %v", err)
+	}
+
+	if len(claimed) != 2 || claimed[0].ID != newer.ID || claimed[0].Attempts != 2 {
+		t.Errorf("Expected both writes once due, oldest first, got %+v", claimed)
+	}
+}
+
+func TestUpdateSupersededRepoWrite(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.ImportRFD(&models.RFD{ID: "0001", RFDMeta: models.RFDMeta{Title: "RFD 0001"}}); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	old := &models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: "0001", Value: "https://example.com/old"}
+	if err := store.CreateRepoWrite(old); err != nil {
+		t.Fatalf("Failed to queue write: %v", err)
+	}
+
+	now := time.Now()
+	claimed, err := store.ClaimRepoWrites(now, now.Add(time.Minute), 10)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Failed to claim write: %v, %+v", err, claimed)
+	}
+
+	// A newer link is queued while the old one is being pushed
+	newer := &models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: "0001", Value: "https://example.com/new"}
+	if err := store.CreateRepoWrite(newer); err != nil {
+		t.Fatalf("Failed to queue write: %v", err)
+	}
+
+	// The old push failed, but it mustn't go back in the queue
+	write := claimed[0]
+	write.LastError = "push failed"
+	write.NextAttemptAt = now.Add(time.Minute)
+	if err := store.UpdateRepoWrite(&write); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected updating a superseded write to fail with sql.ErrNoRows, got %v", err)
+	}
+
+	superseded, err := store.GetRepoWrite(old.ID)
+	if err != nil {
+		t.Fatalf("Failed to get write: %v", err)
+	}
+
+	if superseded.Status != models.RepoWriteDone || superseded.LastError != "superseded" {
+		t.Errorf("Expected the old write to stay superseded, got %s (%s)", superseded.Status, superseded.LastError)
+	}
+
+	claimed, err = store.ClaimRepoWrites(now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("Failed to claim writes: %v", err)
+	}
+
+	if len(claimed) != 1 || claimed[0].ID != newer.ID {
+		t.Errorf("Expected only the newer write to be claimed, got %+v", claimed)
+	}
+}
+
+func TestConcurrentClaimsNeverShareWrites(t *testing.T) {
+	store := newTestStore(t)
+
+	// One write per RFD so none supersede each other
+	const writes = 20
+	for i := 1; i <= writes; i++ {
+		id := fmt.Sprintf("%04d", i)
+		if err := store.ImportRFD(&models.RFD{ID: id, RFDMeta: models.RFDMeta{Title: "RFD " + id}}); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+
+		if err := store.CreateRepoWrite(&models.RepoWrite{Kind: models.RepoWriteDiscussionLink, RFDID: id, Value: "https://example.com/" + id}); err != nil {
+			t.Fatalf("Failed to queue write: %v", err)
+		}
+	}
+
+	now := time.Now()
+	claimed := make(chan models.RepoWrite, writes*2)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			batch, err := store.ClaimRepoWrites(now, now.Add(time.Minute), 3)
+			if err != nil {
+				t.Errorf("Failed to claim writes: %v", err)
+				return
+			}
+
+			for _, write := range batch {
+				claimed <- write
+			}
+		}()
+	}
+	wg.Wait()
+	close(claimed)
+
+	seen := map[int64]bool{}
+	for write := range claimed {
+		if seen[write.ID] {
+			t.Errorf("Write %d was claimed twice", write.ID)
+		}
+		seen[write.ID] = true
+
+		if write.Attempts != 1 {
+			t.Errorf("Expected write %d to be attempted once, got %d", write.ID, write.Attempts)
+		}
+	}
+
+	// 10 claimers of 3 can't take more than the 20 there are
+	if len(seen) != writes {
+		t.Errorf("Expected all %d writes to be claimed, got %d", writes, len(seen))
+	}
+}
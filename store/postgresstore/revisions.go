@@ -0,0 +1,107 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateRevision stores a snapshot of an RFD
+func (s *postgresStore) CreateRevision(revision *models.RFDRevision) error {
+	revision.CreatedAt = time.Now()
+
+	tagsJSON, err := json.Marshal(revision.Tags)
+	if err != nil {
+		return err
+	}
+
+	authorsJSON, err := json.Marshal(revision.Authors)
+	if err != nil {
+		return err
+	}
+
+	return s.db.QueryRow(`
+		INSERT INTO rfd_revisions (rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`, revision.RFDID, revision.Title, string(revision.State), revision.Discussion, string(tagsJSON), string(authorsJSON), revision.Public, revision.ContentMD, revision.CommitSHA, revision.Actor, revision.CreatedAt).Scan(&revision.ID)
+}
+
+// GetRevisions returns every revision of an RFD, newest first
+func (s *postgresStore) GetRevisions(rfdID string) ([]models.RFDRevision, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at
+		FROM rfd_revisions
+		WHERE rfd_id = $1
+		ORDER BY id DESC
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.RFDRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a single revision of an RFD
+func (s *postgresStore) GetRevision(rfdID string, id int64) (*models.RFDRevision, error) {
+	row := s.db.QueryRow(`
+		SELECT id, rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at
+		FROM rfd_revisions
+		WHERE rfd_id = $1 AND id = $2
+	`, rfdID, id)
+
+	revision, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func scanRevision(s scanner) (*models.RFDRevision, error) {
+	var revision models.RFDRevision
+	var tagsJSON, authorsJSON []byte
+
+	err := s.Scan(
+		&revision.ID,
+		&revision.RFDID,
+		&revision.Title,
+		&revision.State,
+		&revision.Discussion,
+		&tagsJSON,
+		&authorsJSON,
+		&revision.Public,
+		&revision.ContentMD,
+		&revision.CommitSHA,
+		&revision.Actor,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tagsJSON, &revision.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(authorsJSON, &revision.Authors); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,359 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const rfdWithAuthorsSelect = `
+	SELECT
+		r.id, r.title, r.state, r.discussion, r.tags, r.public, r.visibility,
+		r.content, r.content_md, r.pr_link, r.created_at, r.modified_at,
+		a.id, a.email, a.name, a.created_at, a.modified_at
+	FROM rfds r
+	LEFT JOIN rfd_authors ra ON r.id = ra.rfd_id
+	LEFT JOIN authors a ON ra.author_id = a.id
+`
+
+func (s *postgresStore) GetRFDByID(id string) (*models.RFD, error) {
+	rows, err := s.db.Query(rfdWithAuthorsSelect+`
+		WHERE r.id = $1
+		ORDER BY a.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rfds, err := scanRFDsWithAuthors(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rfds) == 0 {
+		return nil, nil
+	}
+
+	return &rfds[0], nil
+}
+
+// scanRFDsWithAuthors scans multiple RFDs with joined author data
+func scanRFDsWithAuthors(rows *sql.Rows) ([]models.RFD, error) {
+	rfdMap := make(map[string]*models.RFD)
+	rfdOrder := []string{} // Maintain order
+
+	for rows.Next() {
+		var tagsJSON, visibilityJSON []byte
+		var authorID, authorEmail, authorName sql.NullString
+		var authorCreatedAt, authorModifiedAt sql.NullTime
+
+		var rfd models.RFD
+
+		err := rows.Scan(
+			&rfd.ID, &rfd.Title, &rfd.State, &rfd.Discussion, &tagsJSON, &rfd.Public, &visibilityJSON,
+			&rfd.Content, &rfd.ContentMD, &rfd.PRLink, &rfd.CreatedAt, &rfd.ModifiedAt,
+			&authorID, &authorEmail, &authorName, &authorCreatedAt, &authorModifiedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, exists := rfdMap[rfd.ID]
+		if !exists {
+			rfd.Authors = []models.Author{}
+
+			if err := json.Unmarshal(tagsJSON, &rfd.Tags); err != nil {
+				return nil, err
+			}
+
+			if err := json.Unmarshal(visibilityJSON, &rfd.Visibility); err != nil {
+				return nil, err
+			}
+
+			existing = &rfd
+			rfdMap[rfd.ID] = existing
+			rfdOrder = append(rfdOrder, rfd.ID)
+		}
+
+		// Add author if present (LEFT JOIN means author fields might be NULL)
+		if authorID.Valid && authorID.String != "" {
+			author := models.Author{
+				ID:    authorID.String,
+				Email: authorEmail.String,
+				Name:  authorName.String,
+			}
+			if authorCreatedAt.Valid {
+				author.CreatedAt = authorCreatedAt.Time
+			}
+			if authorModifiedAt.Valid {
+				author.ModifiedAt = authorModifiedAt.Time
+			}
+
+			found := false
+			for _, a := range existing.Authors {
+				if a.ID == author.ID {
+					found = true
+					break
+				}
+			}
+			if !found {
+				existing.Authors = append(existing.Authors, author)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rfds := make([]models.RFD, 0, len(rfdOrder))
+	for _, id := range rfdOrder {
+		rfd := rfdMap[id]
+
+		// Sort authors by name for consistent ordering
+		sort.Slice(rfd.Authors, func(i, j int) bool {
+			return rfd.Authors[i].Name < rfd.Authors[j].Name
+		})
+
+		rfds = append(rfds, *rfd)
+	}
+
+	return rfds, nil
+}
+
+func (s *postgresStore) GetRFDs() ([]models.RFD, error) {
+	rows, err := s.db.Query(rfdWithAuthorsSelect + `
+		ORDER BY r.id ASC, a.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRFDsWithAuthors(rows)
+}
+
+func (s *postgresStore) GetPublicRFDByID(id string) (*models.RFD, error) {
+	rows, err := s.db.Query(rfdWithAuthorsSelect+`
+		WHERE r.id = $1 AND r.public AND jsonb_array_length(r.visibility) = 0
+		ORDER BY a.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rfds, err := scanRFDsWithAuthors(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rfds) == 0 {
+		return nil, nil
+	}
+
+	return &rfds[0], nil
+}
+
+func (s *postgresStore) GetPublicRFDsByTag(tag string) ([]models.RFD, error) {
+	rows, err := s.db.Query(rfdWithAuthorsSelect+`
+		WHERE r.public AND jsonb_array_length(r.visibility) = 0 AND r.tags ? $1
+		ORDER BY r.id ASC, a.id
+	`, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRFDsWithAuthors(rows)
+}
+
+func (s *postgresStore) GetPublicRFDs() ([]models.RFD, error) {
+	rows, err := s.db.Query(rfdWithAuthorsSelect + `
+		WHERE r.public AND jsonb_array_length(r.visibility) = 0
+		ORDER BY r.id ASC, a.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRFDsWithAuthors(rows)
+}
+
+func (s *postgresStore) CreateRFD(rfd *models.RFD) error {
+	// CreateRFD requires reserving the next ID first
+	if rfd.ID == "" {
+		nextID, err := s.ReserveNextRFDID()
+		if err != nil {
+			return err
+		}
+		rfd.ID = nextID
+	}
+
+	return insertRFD(s.db, rfd)
+}
+
+func (s *postgresStore) ImportRFD(rfd *models.RFD) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// ImportRFD allows arbitrary IDs
+	if err := insertRFD(tx, rfd); err != nil {
+		return err
+	}
+
+	// Update next ID if needed
+	if err := raiseNextRFDID(tx, rfd.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRFD(e execer, rfd *models.RFD) error {
+	// Validate RFD data
+	if rfd.ID == "" {
+		return fmt.Errorf("RFD ID cannot be empty")
+	}
+	if rfd.Title == "" {
+		return fmt.Errorf("RFD title cannot be empty")
+	}
+	// Note: Authors are validated at the core layer and linked via relationships after insert
+
+	now := time.Now()
+	rfd.CreatedAt = now
+	rfd.ModifiedAt = now
+
+	tagsJSON, err := json.Marshal(rfd.Tags)
+	if err != nil {
+		return err
+	}
+
+	visibilityJSON, err := marshalVisibility(rfd.Visibility)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(`
+		INSERT INTO rfds (id, title, state, discussion, tags, public, visibility, content, content_md, pr_link, created_at, modified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, rfd.ID, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), rfd.Public, visibilityJSON, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.CreatedAt, rfd.ModifiedAt)
+
+	return err
+}
+
+func (s *postgresStore) UpdateRFD(rfd *models.RFD) error {
+	// Validate RFD data
+	if rfd.ID == "" {
+		return fmt.Errorf("RFD ID cannot be empty")
+	}
+	if rfd.Title == "" {
+		return fmt.Errorf("RFD title cannot be empty")
+	}
+	// Authors are now managed via relationships, not required in RFD update
+
+	rfd.ModifiedAt = time.Now()
+
+	tagsJSON, err := json.Marshal(rfd.Tags)
+	if err != nil {
+		return err
+	}
+
+	visibilityJSON, err := marshalVisibility(rfd.Visibility)
+	if err != nil {
+		return err
+	}
+
+	// If PRLink is empty, preserve the existing one (don't overwrite with empty)
+	// This handles the case where an update comes from main branch merge (no PR context)
+	_, err = s.db.Exec(`
+		UPDATE rfds
+		SET title = $1, state = $2, discussion = $3, tags = $4, public = $5, visibility = $6, content = $7, content_md = $8,
+			pr_link = COALESCE(NULLIF($9, ''), pr_link), modified_at = $10
+		WHERE id = $11
+	`, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), rfd.Public, visibilityJSON, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.ModifiedAt, rfd.ID)
+
+	return err
+}
+
+// DeleteRFD removes an RFD along with its authors, revisions, files and everything else kept for it
+func (s *postgresStore) DeleteRFD(id string) error {
+	_, err := s.db.Exec(`DELETE FROM rfds WHERE id = $1`, id)
+	return err
+}
+
+// LinkAuthorsToRFD creates relationships between an RFD and its authors
+func (s *postgresStore) LinkAuthorsToRFD(rfdID string, authorIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := linkAuthors(tx, rfdID, authorIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateAuthorsForRFD replaces the author relationships for an RFD
+func (s *postgresStore) UpdateAuthorsForRFD(rfdID string, authorIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Remove existing relationships for this RFD
+	if _, err := tx.Exec(`DELETE FROM rfd_authors WHERE rfd_id = $1`, rfdID); err != nil {
+		return err
+	}
+
+	// Add new relationships
+	if err := linkAuthors(tx, rfdID, authorIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func linkAuthors(e execer, rfdID string, authorIDs []string) error {
+	for _, authorID := range authorIDs {
+		// Insert the relationship (ignore if already exists)
+		_, err := e.Exec(`INSERT INTO rfd_authors (rfd_id, author_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, rfdID, authorID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRFDPublic checks if an RFD is marked as public and isn't restricted to any groups
+func (s *postgresStore) IsRFDPublic(id string) (bool, error) {
+	var public bool
+	err := s.db.QueryRow(`SELECT public AND jsonb_array_length(visibility) = 0 FROM rfds WHERE id = $1`, id).Scan(&public)
+	if err != nil {
+		return false, err
+	}
+	return public, nil
+}
+
+// marshalVisibility stores no visibility as an empty array rather than null, so public queries can check its length
+func marshalVisibility(visibility []string) (string, error) {
+	if visibility == nil {
+		visibility = []string{}
+	}
+
+	visibilityJSON, err := json.Marshal(visibility)
+	return string(visibilityJSON), err
+}
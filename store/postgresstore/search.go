@@ -0,0 +1,71 @@
+package postgresstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const defaultSearchLimit = 50
+
+// Search runs a full text search over RFD titles and bodies
+func (s *postgresStore) Search(query string, opts models.SearchOptions) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+
+	if strings.TrimSpace(query) == "" {
+		return results, nil
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	headlineOptions := fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`, models.SearchHighlightStart, models.SearchHighlightEnd)
+
+	rows, err := s.db.Query(`
+		SELECT r.id, ts_headline('english', r.content_md, q, $2), ts_rank(r.search_vector, q) AS rank
+		FROM rfds r, plainto_tsquery('english', $1) q
+		WHERE r.search_vector @@ q AND (NOT $3 OR (r.public AND jsonb_array_length(r.visibility) = 0))
+		ORDER BY rank DESC, r.id
+		LIMIT $4 OFFSET $5
+	`, query, headlineOptions, opts.PublicOnly, limit, opts.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []models.SearchResult{}
+	for rows.Next() {
+		var hit models.SearchResult
+		var snippet string
+		if err := rows.Scan(&hit.RFD.ID, &snippet, &hit.Rank); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		hit.Snippet = models.HighlightSnippet(snippet)
+		hits = append(hits, hit)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		rfd, err := s.GetRFDByID(hit.RFD.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if rfd == nil {
+			continue
+		}
+
+		hit.RFD = *rfd
+		results = append(results, hit)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,101 @@
+package postgresstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func (s *postgresStore) GetTags() ([]models.Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT name, rfds, created_at, modified_at
+		FROM tags
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (s *postgresStore) GetTag(name string) (*models.Tag, error) {
+	row := s.db.QueryRow(`
+		SELECT name, rfds, created_at, modified_at
+		FROM tags
+		WHERE name = $1
+	`, name)
+
+	tag, err := scanTag(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (s *postgresStore) CreateTag(tag *models.Tag) error {
+	now := time.Now()
+	tag.CreatedAt = now
+	tag.ModifiedAt = now
+
+	rfdsJSON, err := json.Marshal(tag.RFDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tags (name, rfds, created_at, modified_at)
+		VALUES ($1, $2, $3, $4)
+	`, tag.Name, string(rfdsJSON), tag.CreatedAt, tag.ModifiedAt)
+
+	return err
+}
+
+func (s *postgresStore) UpdateTag(tag *models.Tag) error {
+	tag.ModifiedAt = time.Now()
+
+	rfdsJSON, err := json.Marshal(tag.RFDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE tags
+		SET rfds = $1, modified_at = $2
+		WHERE name = $3
+	`, string(rfdsJSON), tag.ModifiedAt, tag.Name)
+
+	return err
+}
+
+func scanTag(s scanner) (*models.Tag, error) {
+	var tag models.Tag
+	var rfdsJSON []byte
+
+	err := s.Scan(&tag.Name, &rfdsJSON, &tag.CreatedAt, &tag.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rfdsJSON, &tag.RFDs); err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
@@ -0,0 +1,54 @@
+package postgresstore
+
+import (
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateStateTransition records an RFD changing state
+func (s *postgresStore) CreateStateTransition(transition *models.StateTransition) error {
+	transition.CreatedAt = time.Now()
+
+	return s.db.QueryRow(`
+		INSERT INTO rfd_state_transitions (rfd_id, from_state, to_state, allowed, actor, commit_sha, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, transition.RFDID, string(transition.From), string(transition.To), transition.Allowed, transition.Actor, transition.CommitSHA, transition.CreatedAt).Scan(&transition.ID)
+}
+
+// GetStateTransitions returns every state change of an RFD, oldest first
+func (s *postgresStore) GetStateTransitions(rfdID string) ([]models.StateTransition, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, from_state, to_state, allowed, actor, commit_sha, created_at
+		FROM rfd_state_transitions
+		WHERE rfd_id = $1
+		ORDER BY id
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.StateTransition{}
+	for rows.Next() {
+		var transition models.StateTransition
+
+		if err := rows.Scan(
+			&transition.ID,
+			&transition.RFDID,
+			&transition.From,
+			&transition.To,
+			&transition.Allowed,
+			&transition.Actor,
+			&transition.CommitSHA,
+			&transition.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, rows.Err()
+}
@@ -0,0 +1,142 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const apiTokenColumns = `id, name, owner, owner_groups, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at, groups_checked_at`
+
+// CreateAPIToken stores a new API token
+func (s *sqliteStore) CreateAPIToken(token *models.APIToken) error {
+	token.CreatedAt = time.Now()
+
+	groupsJSON, err := json.Marshal(token.OwnerGroups)
+	if err != nil {
+		return err
+	}
+
+	scopesJSON, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO api_tokens (name, owner, owner_groups, prefix, hash, scopes, expires_at, created_at, groups_checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.Name, token.Owner, string(groupsJSON), token.Prefix, token.Hash, string(scopesJSON), token.ExpiresAt, token.CreatedAt, token.GroupsCheckedAt)
+	if err != nil {
+		return err
+	}
+
+	token.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAPIToken returns a token, nil if there's none with id
+func (s *sqliteStore) GetAPIToken(id int64) (*models.APIToken, error) {
+	return s.getAPIToken(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = ?`, id)
+}
+
+// GetAPITokenByHash returns the token with hash, nil if there's none
+func (s *sqliteStore) GetAPITokenByHash(hash string) (*models.APIToken, error) {
+	return s.getAPIToken(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE hash = ?`, hash)
+}
+
+func (s *sqliteStore) getAPIToken(query string, arg interface{}) (*models.APIToken, error) {
+	rows, err := s.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := scanAPITokens(rows)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+
+	return &tokens[0], nil
+}
+
+// GetAPITokens returns owner's tokens, newest first
+func (s *sqliteStore) GetAPITokens(owner string) ([]models.APIToken, error) {
+	rows, err := s.db.Query(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE owner = ? ORDER BY id DESC`, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAPITokens(rows)
+}
+
+// RevokeAPIToken stops a token from being used, keeping it around for the record
+func (s *sqliteStore) RevokeAPIToken(id int64, revokedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, revokedAt, id)
+	return err
+}
+
+// MarkAPITokenGroupsChecked records that the token owner's groups still cover the token's
+func (s *sqliteStore) MarkAPITokenGroupsChecked(id int64, checkedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET groups_checked_at = ? WHERE id = ?`, checkedAt, id)
+	return err
+}
+
+// TouchAPIToken records when a token was last used
+func (s *sqliteStore) TouchAPIToken(id int64, usedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+func scanAPITokens(rows *sql.Rows) ([]models.APIToken, error) {
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		var token models.APIToken
+		var groupsJSON, scopesJSON string
+		var expiresAt, lastUsedAt, revokedAt, groupsCheckedAt sql.NullTime
+
+		if err := rows.Scan(
+			&token.ID,
+			&token.Name,
+			&token.Owner,
+			&groupsJSON,
+			&token.Prefix,
+			&token.Hash,
+			&scopesJSON,
+			&expiresAt,
+			&lastUsedAt,
+			&revokedAt,
+			&token.CreatedAt,
+			&groupsCheckedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(groupsJSON), &token.OwnerGroups); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(scopesJSON), &token.Scopes); err != nil {
+			return nil, err
+		}
+
+		token.ExpiresAt = timePtr(expiresAt)
+		token.LastUsedAt = timePtr(lastUsedAt)
+		token.RevokedAt = timePtr(revokedAt)
+		token.GroupsCheckedAt = groupsCheckedAt.Time
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
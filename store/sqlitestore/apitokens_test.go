@@ -0,0 +1,67 @@
+package sqlitestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestAPITokens(t *testing.T) {
+	store := newTestStore(t)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	token := &models.APIToken{
+		Name:        "CI",
+		Owner:       "alice@example.com",
+		OwnerGroups: []string{"engineering"},
+		Prefix:      "rfd_abcdef",
+		Hash:        "hash",
+		Scopes:      models.APITokenScopes{models.ScopeRFDsRead, models.ScopeRFDsWrite},
+		ExpiresAt:   &expiresAt,
+	}
+
+	if err := store.CreateAPIToken(token); err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	got, err := store.GetAPITokenByHash("hash")
+	if err != nil || got == nil {
+		t.Fatalf("Expected to find the token by hash, got %v, %v", got, err)
+	}
+
+	if got.ID != token.ID || !reflect.DeepEqual(got.Scopes, token.Scopes) || !reflect.DeepEqual(got.OwnerGroups, token.OwnerGroups) {
+		t.Errorf("Expected %+v, got %+v", token, got)
+	}
+
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || got.LastUsedAt != nil || got.RevokedAt != nil {
+		t.Errorf("Expected only an expiry, got expires %v, last used %v, revoked %v", got.ExpiresAt, got.LastUsedAt, got.RevokedAt)
+	}
+
+	if missing, err := store.GetAPITokenByHash("other"); err != nil || missing != nil {
+		t.Errorf("Expected no token for an unknown hash, got %v, %v", missing, err)
+	}
+
+	now := time.Now()
+	if err := store.TouchAPIToken(token.ID, now); err != nil {
+		t.Fatalf("Failed to touch token: %v", err)
+	}
+
+	if err := store.RevokeAPIToken(token.ID, now); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	tokens, err := store.GetAPITokens("alice@example.com")
+	if err != nil {
+		t.Fatalf("Failed to get tokens: %v", err)
+	}
+
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil || tokens[0].RevokedAt == nil {
+		t.Fatalf("Expected one used, revoked token, got %+v", tokens)
+	}
+
+	if tokens, err := store.GetAPITokens("bob@example.com"); err != nil || len(tokens) != 0 {
+		t.Errorf("Expected no tokens for another owner, got %v, %v", tokens, err)
+	}
+}
@@ -2,15 +2,21 @@ package sqlitestore
 
 import (
 	"database/sql"
+	"embed"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+	"github.com/geekgonecrazy/rfd-tool/store/migrate"
 	_ "modernc.org/sqlite"
 )
 
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
 type sqliteStore struct {
 	db *sql.DB
 }
@@ -34,122 +40,63 @@ func New() (*sqliteStore, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// Pragmas go in the DSN so every connection in the pool gets them. The busy
+	// timeout makes concurrent writers, such as two creates reserving RFD numbers,
+	// wait for each other instead of failing with SQLITE_BUSY.
+	dsn := dbPath + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys and WAL mode for better performance
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		PRAGMA journal_mode = WAL;
-	`)
+	store := &sqliteStore{db: db}
+
+	runner, err := store.migrator()
 	if err != nil {
-		return nil, fmt.Errorf("failed to set pragmas: %w", err)
+		return nil, err
 	}
 
-	store := &sqliteStore{db: db}
-
-	if err := store.initSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	// Refuse to touch a database migrated by a newer binary
+	if err := runner.Check(); err != nil {
+		return nil, err
 	}
 
 	log.Println("SQLite store initialized at", dbPath)
 	return store, nil
 }
 
-func (s *sqliteStore) initSchema() error {
-	// Create tables directly without using migrations
-	tx, err := s.db.Begin()
+func (s *sqliteStore) migrator() (*migrate.Runner, error) {
+	migrations, err := migrate.Load(migrationFiles, "migrations")
 	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Create rfds table (without authors column - authors are stored in relationship table)
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS rfds (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL DEFAULT '',
-		state TEXT NOT NULL DEFAULT '',
-		discussion TEXT NOT NULL DEFAULT '',
-		tags TEXT NOT NULL DEFAULT '[]',
-		public INTEGER NOT NULL DEFAULT 0,
-		content TEXT NOT NULL DEFAULT '',
-		content_md TEXT NOT NULL DEFAULT '',
-		pr_link TEXT NOT NULL DEFAULT '',
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	)`)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Migration: Add pr_link column if it doesn't exist (for existing databases)
-	_, _ = tx.Exec(`ALTER TABLE rfds ADD COLUMN pr_link TEXT NOT NULL DEFAULT ''`)
-
-	// Create tags table
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS tags (
-		name TEXT PRIMARY KEY,
-		rfds TEXT NOT NULL DEFAULT '[]',
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	)`)
-	if err != nil {
-		return err
-	}
+	return &migrate.Runner{
+		DB:          s.db,
+		Migrations:  migrations,
+		Placeholder: func(n int) string { return "?" },
+	}, nil
+}
 
-	// Create meta table
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS meta (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL DEFAULT ''
-	)`)
+// Migrate applies any pending schema migrations
+func (s *sqliteStore) Migrate() error {
+	runner, err := s.migrator()
 	if err != nil {
 		return err
 	}
 
-	// Create authors table with id as PRIMARY KEY
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS authors (
-		id TEXT PRIMARY KEY,
-		email TEXT NOT NULL DEFAULT '',
-		name TEXT NOT NULL DEFAULT '',
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	)`)
-	if err != nil {
-		return err
-	}
+	return runner.Up()
+}
 
-	// Create rfd_authors table for many-to-many relationship
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS rfd_authors (
-		rfd_id TEXT NOT NULL,
-		author_id TEXT NOT NULL,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (rfd_id, author_id),
-		FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE,
-		FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE
-	)`)
+// MigrationStatus lists the known migrations and whether they've been applied
+func (s *sqliteStore) MigrationStatus() ([]models.MigrationStatus, error) {
+	runner, err := s.migrator()
 	if err != nil {
-		return err
-	}
-
-	// Create indexes for common queries
-	indexes := []string{
-		`CREATE INDEX IF NOT EXISTS idx_rfds_state ON rfds(state)`,
-		`CREATE INDEX IF NOT EXISTS idx_rfds_modified ON rfds(modified_at)`,
-		`CREATE INDEX IF NOT EXISTS idx_rfd_authors_rfd_id ON rfd_authors(rfd_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_rfd_authors_author_id ON rfd_authors(author_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_authors_email ON authors(email)`,
-		`CREATE INDEX IF NOT EXISTS idx_authors_name ON authors(name)`,
-	}
-
-	for _, idx := range indexes {
-		_, err = tx.Exec(idx)
-		if err != nil {
-			return err
-		}
+		return nil, err
 	}
 
-	return tx.Commit()
+	return runner.Status()
 }
 
 func (s *sqliteStore) CheckDb() error {
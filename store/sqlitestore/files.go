@@ -0,0 +1,91 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// GetRFDFile returns an RFD's attachment with its content, nil if there's none at path
+func (s *sqliteStore) GetRFDFile(rfdID string, path string) (*models.RFDFile, error) {
+	var file models.RFDFile
+	err := s.db.QueryRow(`
+		SELECT rfd_id, path, content_type, size, sha, content, updated_at
+		FROM rfd_files
+		WHERE rfd_id = ? AND path = ?
+	`, rfdID, path).Scan(
+		&file.RFDID,
+		&file.Path,
+		&file.ContentType,
+		&file.Size,
+		&file.SHA,
+		&file.Content,
+		&file.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// GetRFDFiles lists an RFD's attachments by path, without their content
+func (s *sqliteStore) GetRFDFiles(rfdID string) ([]models.RFDFile, error) {
+	rows, err := s.db.Query(`
+		SELECT rfd_id, path, content_type, size, sha, updated_at
+		FROM rfd_files
+		WHERE rfd_id = ?
+		ORDER BY path
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []models.RFDFile{}
+	for rows.Next() {
+		var file models.RFDFile
+		if err := rows.Scan(
+			&file.RFDID,
+			&file.Path,
+			&file.ContentType,
+			&file.Size,
+			&file.SHA,
+			&file.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+// SaveRFDFile creates or replaces an RFD's attachment
+func (s *sqliteStore) SaveRFDFile(file *models.RFDFile) error {
+	file.UpdatedAt = time.Now()
+
+	_, err := s.db.Exec(`
+		INSERT INTO rfd_files (rfd_id, path, content_type, size, sha, content, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(rfd_id, path) DO UPDATE SET
+			content_type = excluded.content_type,
+			size = excluded.size,
+			sha = excluded.sha,
+			content = excluded.content,
+			updated_at = excluded.updated_at
+	`, file.RFDID, file.Path, file.ContentType, file.Size, file.SHA, file.Content, file.UpdatedAt)
+
+	return err
+}
+
+// DeleteRFDFile removes an RFD's attachment
+func (s *sqliteStore) DeleteRFDFile(rfdID string, path string) error {
+	_, err := s.db.Exec(`DELETE FROM rfd_files WHERE rfd_id = ? AND path = ?`, rfdID, path)
+	return err
+}
@@ -0,0 +1,65 @@
+package sqlitestore
+
+import (
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateRFDMerge records an RFD's branch being merged
+func (s *sqliteStore) CreateRFDMerge(merge *models.RFDMerge) error {
+	merge.CreatedAt = time.Now()
+
+	fastForwardInt := 0
+	if merge.FastForward {
+		fastForwardInt = 1
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO rfd_merges (rfd_id, branch, commit_sha, fast_forward, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, merge.RFDID, merge.Branch, merge.CommitSHA, fastForwardInt, merge.Actor, merge.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	merge.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetRFDMerges returns every merge of an RFD's branch, newest first
+func (s *sqliteStore) GetRFDMerges(rfdID string) ([]models.RFDMerge, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, branch, commit_sha, fast_forward, actor, created_at
+		FROM rfd_merges
+		WHERE rfd_id = ?
+		ORDER BY id DESC
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := []models.RFDMerge{}
+	for rows.Next() {
+		var merge models.RFDMerge
+		var fastForwardInt int
+
+		if err := rows.Scan(
+			&merge.ID,
+			&merge.RFDID,
+			&merge.Branch,
+			&merge.CommitSHA,
+			&fastForwardInt,
+			&merge.Actor,
+			&merge.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		merge.FastForward = fastForwardInt == 1
+		merges = append(merges, merge)
+	}
+
+	return merges, rows.Err()
+}
@@ -26,37 +26,41 @@ func (s *sqliteStore) GetNextRFDID() (string, error) {
 	return fmt.Sprintf("%04d", nextID), nil
 }
 
-func (s *sqliteStore) setNextRFDID(id int64) error {
-	_, err := s.db.Exec(`
-		INSERT INTO meta (key, value) VALUES ('nextRFD', ?)
-		ON CONFLICT(key) DO UPDATE SET value = ?
-	`, fmt.Sprintf("%d", id), fmt.Sprintf("%d", id))
-
-	if err == nil {
-		log.Println("Next RFD ID set to", id)
+// ReserveNextRFDID hands out the next RFD number and moves nextRFD past it in a
+// single statement, so two creates can never be given the same number
+func (s *sqliteStore) ReserveNextRFDID() (string, error) {
+	var value string
+	err := s.db.QueryRow(`
+		INSERT INTO meta (key, value) VALUES ('nextRFD', '2')
+		ON CONFLICT(key) DO UPDATE SET value = CAST(CAST(value AS INTEGER) + 1 AS TEXT)
+		RETURNING value
+	`).Scan(&value)
+	if err != nil {
+		return "", err
 	}
-	return err
-}
 
-func (s *sqliteStore) updateNextRFDIDIfNeeded(rfdID string) error {
-	id, err := strconv.ParseInt(rfdID, 10, 64)
+	next, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	currentNext, err := s.GetNextRFDID()
+	return fmt.Sprintf("%04d", next-1), nil
+}
+
+// RaiseNextRFDID bumps nextRFD to id+1 unless it's already past it
+func (s *sqliteStore) RaiseNextRFDID(rfdID string) error {
+	id, err := strconv.ParseInt(rfdID, 10, 64)
 	if err != nil {
 		return err
 	}
 
-	currentNextInt, _ := strconv.ParseInt(currentNext, 10, 64)
-
-	// If imported ID is >= nextRFD, update nextRFD to be id+1
-	if id >= currentNextInt {
-		return s.setNextRFDID(id + 1)
-	}
+	_, err = s.db.Exec(`
+		INSERT INTO meta (key, value) VALUES ('nextRFD', ?)
+		ON CONFLICT(key) DO UPDATE
+		SET value = CAST(MAX(CAST(value AS INTEGER), CAST(excluded.value AS INTEGER)) AS TEXT)
+	`, fmt.Sprintf("%d", id+1))
 
-	return nil
+	return err
 }
 
 func (s *sqliteStore) EnsureUpdateLatestRFDID() error {
@@ -68,15 +72,19 @@ func (s *sqliteStore) EnsureUpdateLatestRFDID() error {
 	}
 
 	if !maxID.Valid || maxID.String == "" {
-		// No RFDs yet, set to 1
-		return s.setNextRFDID(1)
+		// No RFDs yet, start at 1 unless numbers were already handed out
+		return s.RaiseNextRFDID("0")
 	}
 
-	highestID, err := strconv.ParseInt(maxID.String, 10, 64)
-	if err != nil {
+	// Never lower it, numbers may have been reserved for RFDs that aren't stored yet
+	if err := s.RaiseNextRFDID(maxID.String); err != nil {
 		return err
 	}
 
-	// Set next ID to highest + 1
-	return s.setNextRFDID(highestID + 1)
+	next, err := s.GetNextRFDID()
+	if err == nil {
+		log.Println("Next RFD ID is", next)
+	}
+
+	return err
 }
@@ -0,0 +1,54 @@
+-- Initial schema. Uses IF NOT EXISTS so databases created before
+-- migrations existed are adopted as-is.
+
+-- rfds table (authors are stored in the rfd_authors relationship table)
+CREATE TABLE IF NOT EXISTS rfds (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT '',
+	discussion TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '[]',
+	public INTEGER NOT NULL DEFAULT 0,
+	content TEXT NOT NULL DEFAULT '',
+	content_md TEXT NOT NULL DEFAULT '',
+	pr_link TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	name TEXT PRIMARY KEY,
+	rfds TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS authors (
+	id TEXT PRIMARY KEY,
+	email TEXT NOT NULL DEFAULT '',
+	name TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- many-to-many relationship between rfds and authors
+CREATE TABLE IF NOT EXISTS rfd_authors (
+	rfd_id TEXT NOT NULL,
+	author_id TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (rfd_id, author_id),
+	FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE,
+	FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfds_state ON rfds(state);
+CREATE INDEX IF NOT EXISTS idx_rfds_modified ON rfds(modified_at);
+CREATE INDEX IF NOT EXISTS idx_rfd_authors_rfd_id ON rfd_authors(rfd_id);
+CREATE INDEX IF NOT EXISTS idx_rfd_authors_author_id ON rfd_authors(author_id);
+CREATE INDEX IF NOT EXISTS idx_authors_email ON authors(email);
+CREATE INDEX IF NOT EXISTS idx_authors_name ON authors(name);
@@ -0,0 +1,10 @@
+-- Full text index over RFD titles and bodies, kept in sync by insertRFD/UpdateRFD
+CREATE VIRTUAL TABLE IF NOT EXISTS rfds_fts USING fts5(
+	id UNINDEXED,
+	title,
+	content_md,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO rfds_fts (id, title, content_md)
+SELECT id, title, content_md FROM rfds;
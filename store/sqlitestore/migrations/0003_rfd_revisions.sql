@@ -0,0 +1,31 @@
+-- A snapshot of an RFD every time it changes
+CREATE TABLE IF NOT EXISTS rfd_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rfd_id TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT '',
+	discussion TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '[]',
+	authors TEXT NOT NULL DEFAULT '[]',
+	public INTEGER NOT NULL DEFAULT 0,
+	content_md TEXT NOT NULL DEFAULT '',
+	commit_sha TEXT NOT NULL DEFAULT '',
+	actor TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_revisions_rfd_id ON rfd_revisions(rfd_id, id);
+
+-- Seed history with what every existing RFD says today
+INSERT INTO rfd_revisions (rfd_id, title, state, discussion, tags, authors, public, content_md, actor, created_at)
+SELECT
+	r.id, r.title, r.state, r.discussion, r.tags,
+	COALESCE((
+		SELECT json_group_array(json_object('id', a.id, 'email', a.email, 'name', a.name))
+		FROM rfd_authors ra
+		JOIN authors a ON a.id = ra.author_id
+		WHERE ra.rfd_id = r.id
+	), '[]'),
+	r.public, r.content_md, 'migration', r.modified_at
+FROM rfds r;
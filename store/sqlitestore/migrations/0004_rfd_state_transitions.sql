@@ -0,0 +1,25 @@
+-- Every time an RFD moves between states
+CREATE TABLE IF NOT EXISTS rfd_state_transitions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rfd_id TEXT NOT NULL,
+	from_state TEXT NOT NULL DEFAULT '',
+	to_state TEXT NOT NULL,
+	allowed INTEGER NOT NULL DEFAULT 1,
+	actor TEXT NOT NULL DEFAULT '',
+	commit_sha TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_state_transitions_rfd_id ON rfd_state_transitions(rfd_id, id);
+
+-- Rebuild what history we have from the revisions
+INSERT INTO rfd_state_transitions (rfd_id, from_state, to_state, actor, commit_sha, created_at)
+SELECT rfd_id, previous_state, state, actor, commit_sha, created_at
+FROM (
+	SELECT id, rfd_id, state, actor, commit_sha, created_at,
+		LAG(state, 1, '') OVER (PARTITION BY rfd_id ORDER BY id) AS previous_state
+	FROM rfd_revisions
+)
+WHERE state != previous_state
+ORDER BY id;
@@ -0,0 +1,13 @@
+-- RFD branches merged into the main branch
+CREATE TABLE IF NOT EXISTS rfd_merges (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rfd_id TEXT NOT NULL,
+	branch TEXT NOT NULL,
+	commit_sha TEXT NOT NULL,
+	fast_forward INTEGER NOT NULL DEFAULT 0,
+	actor TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_rfd_merges_rfd_id ON rfd_merges(rfd_id, id);
@@ -0,0 +1,12 @@
+-- Attachments stored alongside an RFD's README.md, served at /:id/files/*path
+CREATE TABLE IF NOT EXISTS rfd_files (
+	rfd_id TEXT NOT NULL,
+	path TEXT NOT NULL,
+	content_type TEXT NOT NULL DEFAULT '',
+	size INTEGER NOT NULL DEFAULT 0,
+	sha TEXT NOT NULL,
+	content BLOB NOT NULL,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (rfd_id, path),
+	FOREIGN KEY (rfd_id) REFERENCES rfds(id) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Personal API tokens, only a sha256 of the token is kept
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	owner_groups TEXT NOT NULL DEFAULT '[]',
+	prefix TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL DEFAULT '[]',
+	expires_at DATETIME,
+	last_used_at DATETIME,
+	revoked_at DATETIME,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_owner ON api_tokens(owner);
@@ -0,0 +1,3 @@
+-- only if: SELECT COUNT(*) = 0 FROM pragma_table_info('rfds') WHERE name = 'pr_link'
+-- Databases created before pr_link existed were adopted by 0001 without it
+ALTER TABLE rfds ADD COLUMN pr_link TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,56 @@
+package sqlitestore
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestSetRFDRelations(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, id := range []string{"0001", "0002", "0003"} {
+		if err := store.ImportRFD(&models.RFD{ID: id, RFDMeta: models.RFDMeta{Title: "RFD " + id}}); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	if err := store.SetRFDRelations("0002", []models.RFDRelation{
+		{Kind: models.Supersedes, TargetID: "0001"},
+		{Kind: models.Related, TargetID: "0003"},
+	}); err != nil {
+		t.Fatalf("Failed to set relations: %v", err)
+	}
+
+	// Replaced, not added to
+	if err := store.SetRFDRelations("0003", []models.RFDRelation{{Kind: models.DependsOn, TargetID: "0009"}}); err != nil {
+		t.Fatalf("Failed to set relations: %v", err)
+	}
+	if err := store.SetRFDRelations("0003", []models.RFDRelation{{Kind: models.DependsOn, TargetID: "0001"}}); err != nil {
+		t.Fatalf("Failed to set relations: %v", err)
+	}
+
+	relations, err := store.GetRFDRelations("0001")
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+
+	want := []models.RFDRelation{
+		{RFDID: "0002", Kind: models.Supersedes, TargetID: "0001"},
+		{RFDID: "0003", Kind: models.DependsOn, TargetID: "0001"},
+	}
+
+	if !reflect.DeepEqual(relations, want) {
+		t.Errorf("Expected inverse relations %+v, got %+v", want, relations)
+	}
+
+	relations, err = store.GetRFDRelations("0002")
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+
+	if len(relations) != 2 {
+		t.Errorf("Expected the 2 relations 0002 declares, got %+v", relations)
+	}
+}
@@ -0,0 +1,178 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const repoWriteColumns = `id, kind, rfd_id, value, status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// CreateRepoWrite queues a repo write. Unfinished writes of the same kind for
+// the RFD are superseded, so an older value can never land after a newer one.
+func (s *sqliteStore) CreateRepoWrite(write *models.RepoWrite) error {
+	now := time.Now()
+	write.Status = models.RepoWritePending
+	write.CreatedAt = now
+	write.UpdatedAt = now
+	if write.NextAttemptAt.IsZero() {
+		write.NextAttemptAt = now
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE repo_writes SET status = ?, last_error = 'superseded', updated_at = ?
+		WHERE rfd_id = ? AND kind = ? AND status != ?
+	`, models.RepoWriteDone, now, write.RFDID, write.Kind, models.RepoWriteDone); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO repo_writes (kind, rfd_id, value, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, write.Kind, write.RFDID, write.Value, write.Status, write.Attempts, write.LastError, write.NextAttemptAt, write.CreatedAt, write.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if write.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimRepoWrites returns up to limit pending writes due by now, oldest first, and
+// pushes their next attempt out to leaseUntil so nothing else picks them up meanwhile.
+// If the claimer dies the writes become due again once the lease runs out.
+func (s *sqliteStore) ClaimRepoWrites(now time.Time, leaseUntil time.Time, limit int) ([]models.RepoWrite, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT `+repoWriteColumns+` FROM repo_writes WHERE status = ? ORDER BY id`, models.RepoWritePending)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := scanRepoWrites(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Times are compared here rather than in SQL, sqlite stores them as text
+	claimed := []models.RepoWrite{}
+	for _, write := range pending {
+		if len(claimed) == limit {
+			break
+		}
+
+		if write.NextAttemptAt.After(now) {
+			continue
+		}
+
+		write.Attempts++
+		write.NextAttemptAt = leaseUntil
+		write.UpdatedAt = now
+
+		if _, err := tx.Exec(`
+			UPDATE repo_writes SET attempts = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?
+		`, write.Attempts, write.NextAttemptAt, write.UpdatedAt, write.ID); err != nil {
+			return nil, err
+		}
+
+		claimed = append(claimed, write)
+	}
+
+	return claimed, tx.Commit()
+}
+
+// UpdateRepoWrite saves a write's status, error and next attempt. Returns sql.ErrNoRows
+// if the write is already done, such as superseded while it was being run.
+func (s *sqliteStore) UpdateRepoWrite(write *models.RepoWrite) error {
+	write.UpdatedAt = time.Now()
+
+	result, err := s.db.Exec(`
+		UPDATE repo_writes SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ? AND status != ?
+	`, write.Status, write.Attempts, write.LastError, write.NextAttemptAt, write.UpdatedAt, write.ID, models.RepoWriteDone)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetRepoWrite returns a queued write, nil if there's none with id
+func (s *sqliteStore) GetRepoWrite(id int64) (*models.RepoWrite, error) {
+	rows, err := s.db.Query(`SELECT `+repoWriteColumns+` FROM repo_writes WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := scanRepoWrites(rows)
+	if err != nil || len(writes) == 0 {
+		return nil, err
+	}
+
+	return &writes[0], nil
+}
+
+// GetRepoWrites returns up to limit writes with status, newest first
+func (s *sqliteStore) GetRepoWrites(status models.RepoWriteStatus, limit int) ([]models.RepoWrite, error) {
+	rows, err := s.db.Query(`
+		SELECT `+repoWriteColumns+` FROM repo_writes
+		WHERE status = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRepoWrites(rows)
+}
+
+func scanRepoWrites(rows *sql.Rows) ([]models.RepoWrite, error) {
+	defer rows.Close()
+
+	writes := []models.RepoWrite{}
+	for rows.Next() {
+		var write models.RepoWrite
+		if err := rows.Scan(
+			&write.ID,
+			&write.Kind,
+			&write.RFDID,
+			&write.Value,
+			&write.Status,
+			&write.Attempts,
+			&write.LastError,
+			&write.NextAttemptAt,
+			&write.CreatedAt,
+			&write.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		writes = append(writes, write)
+	}
+
+	return writes, rows.Err()
+}
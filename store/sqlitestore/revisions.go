@@ -0,0 +1,120 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateRevision stores a snapshot of an RFD
+func (s *sqliteStore) CreateRevision(revision *models.RFDRevision) error {
+	revision.CreatedAt = time.Now()
+
+	tagsJSON, err := json.Marshal(revision.Tags)
+	if err != nil {
+		return err
+	}
+
+	authorsJSON, err := json.Marshal(revision.Authors)
+	if err != nil {
+		return err
+	}
+
+	publicInt := 0
+	if revision.Public {
+		publicInt = 1
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO rfd_revisions (rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, revision.RFDID, revision.Title, string(revision.State), revision.Discussion, string(tagsJSON), string(authorsJSON), publicInt, revision.ContentMD, revision.CommitSHA, revision.Actor, revision.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	revision.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetRevisions returns every revision of an RFD, newest first
+func (s *sqliteStore) GetRevisions(rfdID string) ([]models.RFDRevision, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at
+		FROM rfd_revisions
+		WHERE rfd_id = ?
+		ORDER BY id DESC
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.RFDRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a single revision of an RFD
+func (s *sqliteStore) GetRevision(rfdID string, id int64) (*models.RFDRevision, error) {
+	row := s.db.QueryRow(`
+		SELECT id, rfd_id, title, state, discussion, tags, authors, public, content_md, commit_sha, actor, created_at
+		FROM rfd_revisions
+		WHERE rfd_id = ? AND id = ?
+	`, rfdID, id)
+
+	revision, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func scanRevision(s scanner) (*models.RFDRevision, error) {
+	var revision models.RFDRevision
+	var tagsJSON, authorsJSON string
+	var publicInt int
+
+	err := s.Scan(
+		&revision.ID,
+		&revision.RFDID,
+		&revision.Title,
+		&revision.State,
+		&revision.Discussion,
+		&tagsJSON,
+		&authorsJSON,
+		&publicInt,
+		&revision.ContentMD,
+		&revision.CommitSHA,
+		&revision.Actor,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Public = publicInt == 1
+
+	if err := json.Unmarshal([]byte(tagsJSON), &revision.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(authorsJSON), &revision.Authors); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
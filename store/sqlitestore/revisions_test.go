@@ -0,0 +1,75 @@
+package sqlitestore
+
+import (
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestRevisionsBackfilledByMigration(t *testing.T) {
+	store := newTestStore(t)
+
+	// Roll back to before revisions existed, as an upgraded install would be
+	if _, err := store.db.Exec(`DROP TABLE rfd_revisions; DELETE FROM schema_migrations WHERE version = 3`); err != nil {
+		t.Fatalf("Failed to roll back revisions: %v", err)
+	}
+
+	author := &models.Author{Name: "Alice", Email: "alice@acme.com"}
+	if err := store.CreateAuthor(author); err != nil {
+		t.Fatalf("Failed to create author: %v", err)
+	}
+
+	rfd := &models.RFD{ID: "0001", RFDMeta: models.RFDMeta{Title: "First", State: models.Discussion, Tags: []string{"infra"}}, ContentMD: "Body"}
+	if err := store.ImportRFD(rfd); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	if err := store.LinkAuthorsToRFD(rfd.ID, []string{author.ID}); err != nil {
+		t.Fatalf("Failed to link author: %v", err)
+	}
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	revisions, err := store.GetRevisions(rfd.ID)
+	if err != nil {
+		t.Fatalf("Failed to get revisions: %v", err)
+	}
+
+	if len(revisions) != 1 {
+		t.Fatalf("Expected 1 backfilled revision, got %d", len(revisions))
+	}
+
+	seeded := revisions[0]
+	if seeded.Title != "First" || seeded.ContentMD != "Body" || seeded.Actor != "migration" {
+		t.Errorf("Unexpected backfilled revision: %+v", seeded)
+	}
+
+	if len(seeded.Authors) != 1 || seeded.Authors[0].Email != "alice@acme.com" {
+		t.Errorf("Expected backfilled authors, got %+v", seeded.Authors)
+	}
+
+	if len(seeded.Tags) != 1 || seeded.Tags[0] != "infra" {
+		t.Errorf("Expected backfilled tags, got %+v", seeded.Tags)
+	}
+
+	rfd.State = models.Committed
+	if err := store.CreateRevision(models.NewRFDRevision(rfd, &models.ChangeSource{Actor: "sync", CommitSHA: "abc1234def"})); err != nil {
+		t.Fatalf("Failed to create revision: %v", err)
+	}
+
+	revisions, err = store.GetRevisions(rfd.ID)
+	if err != nil {
+		t.Fatalf("Failed to get revisions: %v", err)
+	}
+
+	if len(revisions) != 2 || revisions[0].State != models.Committed || revisions[0].CommitSHA != "abc1234def" {
+		t.Fatalf("Expected newest revision first, got %+v", revisions)
+	}
+
+	revision, err := store.GetRevision(rfd.ID, revisions[1].ID)
+	if err != nil || revision == nil || revision.State != models.Discussion {
+		t.Errorf("Expected to get the first revision back, got %+v (%v)", revision, err)
+	}
+}
@@ -13,7 +13,7 @@ import (
 func (s *sqliteStore) GetRFDByID(id string) (*models.RFD, error) {
 	rows, err := s.db.Query(`
 		SELECT 
-			r.id, r.title, r.state, r.discussion, r.tags, r.public, 
+			r.id, r.title, r.state, r.discussion, r.tags, r.public, r.visibility,
 			r.content, r.content_md, r.pr_link, r.created_at, r.modified_at,
 			a.id, a.email, a.name, a.created_at, a.modified_at
 		FROM rfds r
@@ -36,7 +36,7 @@ func scanRFDWithAuthors(rows *sql.Rows) (*models.RFD, error) {
 	authorMap := make(map[string]models.Author)
 
 	for rows.Next() {
-		var tagsJSON string
+		var tagsJSON, visibilityJSON string
 		var publicInt int
 		var authorID, authorEmail, authorName sql.NullString
 		var authorCreatedAt, authorModifiedAt sql.NullTime
@@ -46,7 +46,7 @@ func scanRFDWithAuthors(rows *sql.Rows) (*models.RFD, error) {
 		var createdAt, modifiedAt time.Time
 
 		err := rows.Scan(
-			&rfdID, &title, &state, &discussion, &tagsJSON, &publicInt,
+			&rfdID, &title, &state, &discussion, &tagsJSON, &publicInt, &visibilityJSON,
 			&content, &contentMD, &prLink, &createdAt, &modifiedAt,
 			&authorID, &authorEmail, &authorName, &authorCreatedAt, &authorModifiedAt,
 		)
@@ -76,6 +76,10 @@ func scanRFDWithAuthors(rows *sql.Rows) (*models.RFD, error) {
 			if err := json.Unmarshal([]byte(tagsJSON), &rfd.Tags); err != nil {
 				return nil, err
 			}
+
+			if err := json.Unmarshal([]byte(visibilityJSON), &rfd.Visibility); err != nil {
+				return nil, err
+			}
 		}
 
 		// Add author if present (LEFT JOIN means author fields might be NULL)
@@ -122,7 +126,7 @@ func scanRFDWithAuthors(rows *sql.Rows) (*models.RFD, error) {
 func (s *sqliteStore) GetRFDs() ([]models.RFD, error) {
 	rows, err := s.db.Query(`
 		SELECT 
-			r.id, r.title, r.state, r.discussion, r.tags, r.public, 
+			r.id, r.title, r.state, r.discussion, r.tags, r.public, r.visibility,
 			r.content, r.content_md, r.pr_link, r.created_at, r.modified_at,
 			a.id, a.email, a.name, a.created_at, a.modified_at
 		FROM rfds r
@@ -144,7 +148,7 @@ func scanRFDsWithAuthors(rows *sql.Rows) ([]models.RFD, error) {
 	rfdOrder := []string{} // Maintain order
 
 	for rows.Next() {
-		var tagsJSON string
+		var tagsJSON, visibilityJSON string
 		var publicInt int
 		var authorID, authorEmail, authorName sql.NullString
 		var authorCreatedAt, authorModifiedAt sql.NullTime
@@ -154,7 +158,7 @@ func scanRFDsWithAuthors(rows *sql.Rows) ([]models.RFD, error) {
 		var createdAt, modifiedAt time.Time
 
 		err := rows.Scan(
-			&rfdID, &title, &state, &discussion, &tagsJSON, &publicInt,
+			&rfdID, &title, &state, &discussion, &tagsJSON, &publicInt, &visibilityJSON,
 			&content, &contentMD, &prLink, &createdAt, &modifiedAt,
 			&authorID, &authorEmail, &authorName, &authorCreatedAt, &authorModifiedAt,
 		)
@@ -186,6 +190,10 @@ func scanRFDsWithAuthors(rows *sql.Rows) ([]models.RFD, error) {
 				return nil, err
 			}
 
+			if err := json.Unmarshal([]byte(visibilityJSON), &rfd.Visibility); err != nil {
+				return nil, err
+			}
+
 			rfdMap[rfdID] = rfd
 			rfdOrder = append(rfdOrder, rfdID)
 		}
@@ -234,13 +242,13 @@ func scanRFDsWithAuthors(rows *sql.Rows) ([]models.RFD, error) {
 func (s *sqliteStore) GetPublicRFDByID(id string) (*models.RFD, error) {
 	rows, err := s.db.Query(`
 		SELECT 
-			r.id, r.title, r.state, r.discussion, r.tags, r.public, 
+			r.id, r.title, r.state, r.discussion, r.tags, r.public, r.visibility,
 			r.content, r.content_md, r.pr_link, r.created_at, r.modified_at,
 			a.id, a.email, a.name, a.created_at, a.modified_at
 		FROM rfds r
 		LEFT JOIN rfd_authors ra ON r.id = ra.rfd_id
 		LEFT JOIN authors a ON ra.author_id = a.id
-		WHERE r.id = ? AND r.public = 1
+		WHERE r.id = ? AND r.public = 1 AND json_array_length(r.visibility) = 0
 		ORDER BY a.id
 	`, id)
 	if err != nil {
@@ -275,13 +283,13 @@ func (s *sqliteStore) GetPublicRFDsByTag(tag string) ([]models.RFD, error) {
 func (s *sqliteStore) GetPublicRFDs() ([]models.RFD, error) {
 	rows, err := s.db.Query(`
 		SELECT 
-			r.id, r.title, r.state, r.discussion, r.tags, r.public, 
+			r.id, r.title, r.state, r.discussion, r.tags, r.public, r.visibility,
 			r.content, r.content_md, r.pr_link, r.created_at, r.modified_at,
 			a.id, a.email, a.name, a.created_at, a.modified_at
 		FROM rfds r
 		LEFT JOIN rfd_authors ra ON r.id = ra.rfd_id
 		LEFT JOIN authors a ON ra.author_id = a.id
-		WHERE r.public = 1
+		WHERE r.public = 1 AND json_array_length(r.visibility) = 0
 		ORDER BY r.id ASC, a.id
 	`)
 	if err != nil {
@@ -293,9 +301,9 @@ func (s *sqliteStore) GetPublicRFDs() ([]models.RFD, error) {
 }
 
 func (s *sqliteStore) CreateRFD(rfd *models.RFD) error {
-	// CreateRFD requires getting next ID first
+	// CreateRFD requires reserving the next ID first
 	if rfd.ID == "" {
-		nextID, err := s.GetNextRFDID()
+		nextID, err := s.ReserveNextRFDID()
 		if err != nil {
 			return err
 		}
@@ -312,7 +320,7 @@ func (s *sqliteStore) ImportRFD(rfd *models.RFD) error {
 	}
 
 	// Update next ID if needed
-	return s.updateNextRFDIDIfNeeded(rfd.ID)
+	return s.RaiseNextRFDID(rfd.ID)
 }
 
 func (s *sqliteStore) insertRFD(rfd *models.RFD) error {
@@ -334,17 +342,35 @@ func (s *sqliteStore) insertRFD(rfd *models.RFD) error {
 		return err
 	}
 
+	visibilityJSON, err := marshalVisibility(rfd.Visibility)
+	if err != nil {
+		return err
+	}
+
 	publicInt := 0
 	if rfd.Public {
 		publicInt = 1
 	}
 
-	_, err = s.db.Exec(`
-		INSERT INTO rfds (id, title, state, discussion, tags, public, content, content_md, pr_link, created_at, modified_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, rfd.ID, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.CreatedAt, rfd.ModifiedAt)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	return err
+	_, err = tx.Exec(`
+		INSERT INTO rfds (id, title, state, discussion, tags, public, visibility, content, content_md, pr_link, created_at, modified_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rfd.ID, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, visibilityJSON, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.CreatedAt, rfd.ModifiedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := updateSearchIndex(tx, rfd); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *sqliteStore) UpdateRFD(rfd *models.RFD) error {
@@ -364,33 +390,80 @@ func (s *sqliteStore) UpdateRFD(rfd *models.RFD) error {
 		return err
 	}
 
+	visibilityJSON, err := marshalVisibility(rfd.Visibility)
+	if err != nil {
+		return err
+	}
+
 	publicInt := 0
 	if rfd.Public {
 		publicInt = 1
 	}
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// If PRLink is empty, preserve the existing one (don't overwrite with empty)
 	// This handles the case where an update comes from main branch merge (no PR context)
 	if rfd.PRLink == "" {
-		_, err = s.db.Exec(`
+		_, err = tx.Exec(`
 			UPDATE rfds
-			SET title = ?, state = ?, discussion = ?, tags = ?, public = ?, content = ?, content_md = ?, modified_at = ?
+			SET title = ?, state = ?, discussion = ?, tags = ?, public = ?, visibility = ?, content = ?, content_md = ?, modified_at = ?
 			WHERE id = ?
-		`, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, rfd.Content, rfd.ContentMD, rfd.ModifiedAt, rfd.ID)
+		`, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, visibilityJSON, rfd.Content, rfd.ContentMD, rfd.ModifiedAt, rfd.ID)
 	} else {
-		_, err = s.db.Exec(`
+		_, err = tx.Exec(`
 			UPDATE rfds
-			SET title = ?, state = ?, discussion = ?, tags = ?, public = ?, content = ?, content_md = ?, pr_link = ?, modified_at = ?
+			SET title = ?, state = ?, discussion = ?, tags = ?, public = ?, visibility = ?, content = ?, content_md = ?, pr_link = ?, modified_at = ?
 			WHERE id = ?
-		`, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.ModifiedAt, rfd.ID)
+		`, rfd.Title, string(rfd.State), rfd.Discussion, string(tagsJSON), publicInt, visibilityJSON, rfd.Content, rfd.ContentMD, rfd.PRLink, rfd.ModifiedAt, rfd.ID)
 	}
+	if err != nil {
+		return err
+	}
+
+	if err := updateSearchIndex(tx, rfd); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
+// DeleteRFD removes an RFD along with its authors, revisions, files and everything else kept for it
+func (s *sqliteStore) DeleteRFD(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rfds_fts WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM rfds WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateSearchIndex replaces the RFD's row in the full text index
+func updateSearchIndex(tx *sql.Tx, rfd *models.RFD) error {
+	if _, err := tx.Exec(`DELETE FROM rfds_fts WHERE id = ?`, rfd.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`INSERT INTO rfds_fts (id, title, content_md) VALUES (?, ?, ?)`, rfd.ID, rfd.Title, rfd.ContentMD)
 	return err
 }
 
 func scanRFD(s scanner) (*models.RFD, error) {
 	var rfd models.RFD
-	var tagsJSON string
+	var tagsJSON, visibilityJSON string
 	var publicInt int
 
 	err := s.Scan(
@@ -400,6 +473,7 @@ func scanRFD(s scanner) (*models.RFD, error) {
 		&rfd.Discussion,
 		&tagsJSON,
 		&publicInt,
+		&visibilityJSON,
 		&rfd.Content,
 		&rfd.ContentMD,
 		&rfd.PRLink,
@@ -419,6 +493,10 @@ func scanRFD(s scanner) (*models.RFD, error) {
 		return nil, err
 	}
 
+	if err := json.Unmarshal([]byte(visibilityJSON), &rfd.Visibility); err != nil {
+		return nil, err
+	}
+
 	return &rfd, nil
 }
 
@@ -446,12 +524,22 @@ func (s *sqliteStore) UpdateAuthorsForRFD(rfdID string, authorIDs []string) erro
 	return s.LinkAuthorsToRFD(rfdID, authorIDs)
 }
 
-// IsRFDPublic checks if an RFD is marked as public
+// IsRFDPublic checks if an RFD is marked as public and isn't restricted to any groups
 func (s *sqliteStore) IsRFDPublic(id string) (bool, error) {
 	var publicInt int
-	err := s.db.QueryRow(`SELECT public FROM rfds WHERE id = ?`, id).Scan(&publicInt)
+	err := s.db.QueryRow(`SELECT public AND json_array_length(visibility) = 0 FROM rfds WHERE id = ?`, id).Scan(&publicInt)
 	if err != nil {
 		return false, err
 	}
 	return publicInt == 1, nil
 }
+
+// marshalVisibility stores no visibility as an empty array rather than null, so public queries can check its length
+func marshalVisibility(visibility []string) (string, error) {
+	if visibility == nil {
+		visibility = []string{}
+	}
+
+	visibilityJSON, err := json.Marshal(visibility)
+	return string(visibilityJSON), err
+}
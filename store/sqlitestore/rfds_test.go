@@ -0,0 +1,153 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func TestRestrictedRFDsAreNeverPublic(t *testing.T) {
+	store := newTestStore(t)
+
+	rfds := []models.RFD{
+		{ID: "0001", RFDMeta: models.RFDMeta{Title: "Public", Public: true}},
+		{ID: "0002", RFDMeta: models.RFDMeta{Title: "Incident review", Public: true, Visibility: []string{"security-team"}}},
+	}
+
+	for i := range rfds {
+		if err := store.ImportRFD(&rfds[i]); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	rfd, err := store.GetRFDByID("0002")
+	if err != nil {
+		t.Fatalf("Failed to get RFD: %v", err)
+	}
+
+	if !reflect.DeepEqual(rfd.Visibility, []string{"security-team"}) {
+		t.Errorf("Expected visibility to round trip, got %v", rfd.Visibility)
+	}
+
+	public, err := store.GetPublicRFDs()
+	if err != nil {
+		t.Fatalf("Failed to get public RFDs: %v", err)
+	}
+
+	if len(public) != 1 || public[0].ID != "0001" {
+		t.Errorf("Expected only 0001 to be public, got %v", public)
+	}
+
+	if isPublic, err := store.IsRFDPublic("0002"); err != nil || isPublic {
+		t.Errorf("Expected restricted RFD not to be public, got %v, %v", isPublic, err)
+	}
+
+	if rfd, err := store.GetPublicRFDByID("0002"); err != nil || rfd != nil {
+		t.Errorf("Expected restricted RFD not to be found publicly, got %v, %v", rfd, err)
+	}
+
+	// Lifting the restriction makes it public again
+	rfds[1].Visibility = nil
+	if err := store.UpdateRFD(&rfds[1]); err != nil {
+		t.Fatalf("Failed to update RFD: %v", err)
+	}
+
+	if isPublic, err := store.IsRFDPublic("0002"); err != nil || !isPublic {
+		t.Errorf("Expected unrestricted RFD to be public, got %v, %v", isPublic, err)
+	}
+}
+
+func TestAdoptDatabaseWithoutPRLink(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("dataPath: "+dir+"/\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := config.Load(configFile); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// An rfds table from before pr_link or migrations existed
+	db, err := sql.Open("sqlite", filepath.Join(dir, "rfd.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	if _, err := db.Exec(`CREATE TABLE rfds (
+		id TEXT PRIMARY KEY,
+		title TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL DEFAULT '',
+		discussion TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '[]',
+		public INTEGER NOT NULL DEFAULT 0,
+		content TEXT NOT NULL DEFAULT '',
+		content_md TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		modified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("Failed to create old rfds table: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO rfds (id, title) VALUES ('0001', 'Old')`); err != nil {
+		t.Fatalf("Failed to insert old RFD: %v", err)
+	}
+	db.Close()
+
+	store, err := New()
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.db.Close() })
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	rfd, err := store.GetRFDByID("0001")
+	if err != nil {
+		t.Fatalf("Failed to get RFD: %v", err)
+	}
+
+	if rfd == nil || rfd.Title != "Old" || rfd.PRLink != "" {
+		t.Errorf("Expected the old RFD with an empty PR link, got %+v", rfd)
+	}
+
+	if err := store.ImportRFD(&models.RFD{ID: "0002", RFDMeta: models.RFDMeta{Title: "New"}, PRLink: "https://example.com/pull/2"}); err != nil {
+		t.Errorf("Failed to import RFD: %v", err)
+	}
+}
+
+func TestDeleteRFD(t *testing.T) {
+	store := newTestStore(t)
+
+	rfd := &models.RFD{ID: "0001", RFDMeta: models.RFDMeta{Title: "Replication"}, ContentMD: "Replication broke."}
+	if err := store.ImportRFD(rfd); err != nil {
+		t.Fatalf("Failed to import RFD: %v", err)
+	}
+
+	if err := store.CreateRevision(&models.RFDRevision{RFDID: "0001", Title: rfd.Title}); err != nil {
+		t.Fatalf("Failed to create revision: %v", err)
+	}
+
+	if err := store.DeleteRFD("0001"); err != nil {
+		t.Fatalf("Failed to delete RFD: %v", err)
+	}
+
+	if rfd, err := store.GetRFDByID("0001"); err != nil || rfd != nil {
+		t.Errorf("Expected the RFD to be gone, got %v, %v", rfd, err)
+	}
+
+	if revisions, err := store.GetRevisions("0001"); err != nil || len(revisions) != 0 {
+		t.Errorf("Expected its revisions to be gone, got %v, %v", revisions, err)
+	}
+
+	if results, err := store.Search("replication", models.SearchOptions{}); err != nil || len(results) != 0 {
+		t.Errorf("Expected it to be gone from search, got %v, %v", results, err)
+	}
+}
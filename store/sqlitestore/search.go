@@ -0,0 +1,90 @@
+package sqlitestore
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+const defaultSearchLimit = 50
+
+// Search runs a full text search over RFD titles and bodies
+func (s *sqliteStore) Search(query string, opts models.SearchOptions) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+
+	match := ftsQuery(query)
+	if match == "" {
+		return results, nil
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	publicOnly := 0
+	if opts.PublicOnly {
+		publicOnly = 1
+	}
+
+	rows, err := s.db.Query(`
+		SELECT f.id, snippet(rfds_fts, 2, ?, ?, '…', 24), bm25(rfds_fts, 0.0, 10.0, 1.0) AS rank
+		FROM rfds_fts f
+		JOIN rfds r ON r.id = f.id
+		WHERE rfds_fts MATCH ? AND (? = 0 OR (r.public = 1 AND json_array_length(r.visibility) = 0))
+		ORDER BY rank, f.id
+		LIMIT ? OFFSET ?
+	`, models.SearchHighlightStart, models.SearchHighlightEnd, match, publicOnly, limit, opts.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []models.SearchResult{}
+	for rows.Next() {
+		var hit models.SearchResult
+		var snippet string
+		if err := rows.Scan(&hit.RFD.ID, &snippet, &hit.Rank); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		hit.Snippet = models.HighlightSnippet(snippet)
+		hits = append(hits, hit)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		rfd, err := s.GetRFDByID(hit.RFD.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if rfd == nil {
+			continue
+		}
+
+		hit.RFD = *rfd
+		results = append(results, hit)
+	}
+
+	return results, nil
+}
+
+// ftsQuery turns user input into a safe FTS5 query: every word must match, as a prefix
+func ftsQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+word+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,102 @@
+package sqlitestore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekgonecrazy/rfd-tool/config"
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+func newTestStore(t *testing.T) *sqliteStore {
+	t.Helper()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("dataPath: "+dir+"/\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := config.Load(configFile); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	store, err := New()
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return store
+}
+
+func TestSearch(t *testing.T) {
+	store := newTestStore(t)
+
+	rfds := []models.RFD{
+		{ID: "0001", RFDMeta: models.RFDMeta{Title: "Database replication", Public: true}, ContentMD: "We replicate <b>postgres</b> across regions."},
+		{ID: "0002", RFDMeta: models.RFDMeta{Title: "Secret launch plans", Public: false}, ContentMD: "Replication of the launch to every region."},
+		{ID: "0003", RFDMeta: models.RFDMeta{Title: "Logging", Public: true}, ContentMD: "Nothing relevant here."},
+	}
+
+	for i := range rfds {
+		if err := store.ImportRFD(&rfds[i]); err != nil {
+			t.Fatalf("Failed to import RFD: %v", err)
+		}
+	}
+
+	results, err := store.Search("replicat", models.SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	// Title matches are weighted above body matches
+	if results[0].RFD.ID != "0001" {
+		t.Errorf("Expected 0001 first, got %s", results[0].RFD.ID)
+	}
+
+	if !strings.Contains(results[0].Snippet, "<mark>replicate</mark>") || !strings.Contains(results[0].Snippet, "&lt;b&gt;postgres") {
+		t.Errorf("Expected escaped, highlighted snippet, got %q", results[0].Snippet)
+	}
+
+	results, err = store.Search("replication", models.SearchOptions{PublicOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	for _, result := range results {
+		if !result.RFD.Public {
+			t.Errorf("Public search returned private RFD %s", result.RFD.ID)
+		}
+	}
+
+	// Updates are reflected in the index
+	rfds[2].ContentMD = "Now we talk about replication too."
+	if err := store.UpdateRFD(&rfds[2]); err != nil {
+		t.Fatalf("Failed to update RFD: %v", err)
+	}
+
+	results, err = store.Search("replication", models.SearchOptions{PublicOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected 2 public results after update, got %d", len(results))
+	}
+
+	// FTS syntax in user input must not break the query
+	if _, err := store.Search(`"unbalanced AND (OR NEAR`, models.SearchOptions{}); err != nil {
+		t.Errorf("Expected query syntax to be escaped, got %v", err)
+	}
+}
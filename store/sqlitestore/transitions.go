@@ -0,0 +1,66 @@
+package sqlitestore
+
+import (
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// CreateStateTransition records an RFD changing state
+func (s *sqliteStore) CreateStateTransition(transition *models.StateTransition) error {
+	transition.CreatedAt = time.Now()
+
+	allowedInt := 0
+	if transition.Allowed {
+		allowedInt = 1
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO rfd_state_transitions (rfd_id, from_state, to_state, allowed, actor, commit_sha, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, transition.RFDID, string(transition.From), string(transition.To), allowedInt, transition.Actor, transition.CommitSHA, transition.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	transition.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetStateTransitions returns every state change of an RFD, oldest first
+func (s *sqliteStore) GetStateTransitions(rfdID string) ([]models.StateTransition, error) {
+	rows, err := s.db.Query(`
+		SELECT id, rfd_id, from_state, to_state, allowed, actor, commit_sha, created_at
+		FROM rfd_state_transitions
+		WHERE rfd_id = ?
+		ORDER BY id
+	`, rfdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.StateTransition{}
+	for rows.Next() {
+		var transition models.StateTransition
+		var allowedInt int
+
+		if err := rows.Scan(
+			&transition.ID,
+			&transition.RFDID,
+			&transition.From,
+			&transition.To,
+			&allowedInt,
+			&transition.Actor,
+			&transition.CommitSHA,
+			&transition.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		transition.Allowed = allowedInt == 1
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, rows.Err()
+}
@@ -1,6 +1,10 @@
 package store
 
-import "github.com/geekgonecrazy/rfd-tool/models"
+import (
+	"time"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
 
 // Store is an interface that the storage implementers should implement
 type Store interface {
@@ -10,6 +14,7 @@ type Store interface {
 	CreateRFD(rfd *models.RFD) error
 	UpdateRFD(sponsorship *models.RFD) error
 	ImportRFD(rfd *models.RFD) error
+	DeleteRFD(id string) error
 
 	// Public RFD methods
 	GetPublicRFDs() ([]models.RFD, error)
@@ -17,6 +22,48 @@ type Store interface {
 	GetPublicRFDsByTag(tag string) ([]models.RFD, error)
 	IsRFDPublic(id string) (bool, error)
 
+	// Revision methods
+	CreateRevision(revision *models.RFDRevision) error
+	GetRevisions(rfdID string) ([]models.RFDRevision, error)
+	GetRevision(rfdID string, id int64) (*models.RFDRevision, error)
+
+	// State transition methods
+	CreateStateTransition(transition *models.StateTransition) error
+	GetStateTransitions(rfdID string) ([]models.StateTransition, error)
+
+	// Merge methods
+	CreateRFDMerge(merge *models.RFDMerge) error
+	GetRFDMerges(rfdID string) ([]models.RFDMerge, error)
+
+	// Relation methods
+	GetRFDRelations(rfdID string) ([]models.RFDRelation, error)
+	SetRFDRelations(rfdID string, relations []models.RFDRelation) error
+
+	// File methods
+	GetRFDFile(rfdID string, path string) (*models.RFDFile, error)
+	GetRFDFiles(rfdID string) ([]models.RFDFile, error)
+	SaveRFDFile(file *models.RFDFile) error
+	DeleteRFDFile(rfdID string, path string) error
+
+	// Repo write queue methods
+	CreateRepoWrite(write *models.RepoWrite) error
+	ClaimRepoWrites(now time.Time, leaseUntil time.Time, limit int) ([]models.RepoWrite, error)
+	UpdateRepoWrite(write *models.RepoWrite) error
+	GetRepoWrite(id int64) (*models.RepoWrite, error)
+	GetRepoWrites(status models.RepoWriteStatus, limit int) ([]models.RepoWrite, error)
+
+	// API token methods
+	CreateAPIToken(token *models.APIToken) error
+	GetAPIToken(id int64) (*models.APIToken, error)
+	GetAPITokenByHash(hash string) (*models.APIToken, error)
+	GetAPITokens(owner string) ([]models.APIToken, error)
+	RevokeAPIToken(id int64, revokedAt time.Time) error
+	TouchAPIToken(id int64, usedAt time.Time) error
+	MarkAPITokenGroupsChecked(id int64, checkedAt time.Time) error
+
+	// Search methods
+	Search(query string, opts models.SearchOptions) ([]models.SearchResult, error)
+
 	// Tag methods
 	GetTags() ([]models.Tag, error)
 	GetTag(tag string) (*models.Tag, error)
@@ -41,5 +88,11 @@ type Store interface {
 	// Meta methods
 	EnsureUpdateLatestRFDID() error
 	GetNextRFDID() (string, error)
+	ReserveNextRFDID() (string, error)
+	RaiseNextRFDID(rfdID string) error
 	CheckDb() error
+
+	// Migration methods
+	Migrate() error
+	MigrationStatus() ([]models.MigrationStatus, error)
 }
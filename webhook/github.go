@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/geekgonecrazy/rfd-tool/models"
+)
+
+// GitHub event names sent in the X-GitHub-Event header
+const (
+	GithubEventPing = "ping"
+	GithubEventPush = "push"
+)
+
+var rfdNumberRegex = regexp.MustCompile(`^\d{4}$`)
+
+// PushEvent is the part of GitHub's push event payload we care about
+type PushEvent struct {
+	Ref        string       `json:"ref"`
+	Before     string       `json:"before"`
+	After      string       `json:"after"`
+	Created    bool         `json:"created"`
+	Deleted    bool         `json:"deleted"`
+	Commits    []PushCommit `json:"commits"`
+	HeadCommit *PushCommit  `json:"head_commit"`
+	Pusher     PushUser     `json:"pusher"`
+}
+
+// PushCommit is a single commit included in a push event
+type PushCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// PushUser is the user that pushed
+type PushUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Branch returns the branch name pushed to, or empty if the ref isn't a branch
+func (e *PushEvent) Branch() string {
+	if !strings.HasPrefix(e.Ref, "refs/heads/") {
+		return ""
+	}
+
+	return strings.TrimPrefix(e.Ref, "refs/heads/")
+}
+
+// ChangedRFDs returns the RFD numbers whose README.md or attachments were touched by the push
+func (e *PushEvent) ChangedRFDs(folder string) []string {
+	folder = strings.Trim(folder, "/")
+
+	seen := map[string]bool{}
+	rfdNums := []string{}
+
+	commits := e.Commits
+	if len(commits) == 0 && e.HeadCommit != nil {
+		commits = []PushCommit{*e.HeadCommit}
+	}
+
+	for _, commit := range commits {
+		paths := []string{}
+		paths = append(paths, commit.Added...)
+		paths = append(paths, commit.Removed...)
+		paths = append(paths, commit.Modified...)
+
+		for _, path := range paths {
+			rfdNum, ok := rfdFromPath(folder, path)
+			if ok && !seen[rfdNum] {
+				seen[rfdNum] = true
+				rfdNums = append(rfdNums, rfdNum)
+			}
+		}
+	}
+
+	sort.Strings(rfdNums)
+
+	return rfdNums
+}
+
+// RemovedRFDs returns the RFD numbers whose README.md the push deleted and didn't add back
+func (e *PushEvent) RemovedRFDs(folder string) []string {
+	folder = strings.Trim(folder, "/")
+
+	removed := map[string]bool{}
+
+	commits := e.Commits
+	if len(commits) == 0 && e.HeadCommit != nil {
+		commits = []PushCommit{*e.HeadCommit}
+	}
+
+	for _, commit := range commits {
+		for _, path := range commit.Removed {
+			if rfdNum, ok := rfdReadmeFromPath(folder, path); ok {
+				removed[rfdNum] = true
+			}
+		}
+
+		paths := []string{}
+		paths = append(paths, commit.Added...)
+		paths = append(paths, commit.Modified...)
+
+		for _, path := range paths {
+			if rfdNum, ok := rfdReadmeFromPath(folder, path); ok {
+				removed[rfdNum] = false
+			}
+		}
+	}
+
+	rfdNums := []string{}
+	for rfdNum, gone := range removed {
+		if gone {
+			rfdNums = append(rfdNums, rfdNum)
+		}
+	}
+
+	sort.Strings(rfdNums)
+
+	return rfdNums
+}
+
+// rfdReadmeFromPath extracts NNNN from folder/NNNN/README.md
+func rfdReadmeFromPath(folder string, path string) (string, bool) {
+	rfdNum, ok := rfdFromPath(folder, path)
+	if !ok {
+		return "", false
+	}
+
+	prefix := ""
+	if folder != "" {
+		prefix = folder + "/"
+	}
+
+	return rfdNum, path == prefix+rfdNum+"/README.md"
+}
+
+// rfdFromPath extracts NNNN from folder/NNNN/README.md or any other file under folder/NNNN/
+func rfdFromPath(folder string, path string) (string, bool) {
+	prefix := ""
+	if folder != "" {
+		prefix = folder + "/"
+	}
+
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) < 2 || !rfdNumberRegex.MatchString(parts[0]) {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+// Actor returns who pushed, for logging and attribution
+func (e *PushEvent) Actor() string {
+	return models.FormatAuthor(e.Pusher.Name, e.Pusher.Email)
+}
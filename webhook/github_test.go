@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPushEventChangedRFDs(t *testing.T) {
+	payload := `{
+		"ref": "refs/heads/0042",
+		"commits": [
+			{"id": "a", "added": ["rfds/0042/README.md"], "modified": [], "removed": []},
+			{"id": "b", "added": [], "modified": ["rfds/0007/README.md", "rfds/0042/README.md", "README.md"], "removed": []},
+			{"id": "c", "added": ["rfds/0042/diagram.png", "rfds/notes/README.md", "other/0001/README.md"], "modified": [], "removed": ["rfds/0003/README.md"]},
+			{"id": "d", "added": ["rfds/0009/images/flow.png", "rfds/0010"], "modified": [], "removed": []}
+		],
+		"pusher": {"name": "alice", "email": "alice@acme.com"}
+	}`
+
+	var event PushEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("Failed to parse push event: %v", err)
+	}
+
+	if event.Branch() != "0042" {
+		t.Errorf("Expected branch '0042', got '%s'", event.Branch())
+	}
+
+	expected := []string{"0003", "0007", "0009", "0042"}
+	if got := event.ChangedRFDs("rfds"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got := event.ChangedRFDs("/rfds/"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected folder slashes to be ignored, got %v", got)
+	}
+
+	if event.Actor() != "alice <alice@acme.com>" {
+		t.Errorf("Unexpected actor '%s'", event.Actor())
+	}
+}
+
+func TestPushEventTagRef(t *testing.T) {
+	event := PushEvent{Ref: "refs/tags/v1.0.0"}
+
+	if event.Branch() != "" {
+		t.Errorf("Expected no branch for tag push, got '%s'", event.Branch())
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	signature := "sha256=" + computeHMAC(body, "secret")
+
+	if !VerifySignature(body, signature, "secret") {
+		t.Error("Expected signature to verify")
+	}
+
+	if VerifySignature(body, signature, "other-secret") {
+		t.Error("Expected signature with wrong secret to fail")
+	}
+
+	if VerifySignature(body, "", "secret") {
+		t.Error("Expected empty signature to fail")
+	}
+}
+
+func TestPushEventRemovedRFDs(t *testing.T) {
+	payload := `{
+		"ref": "refs/heads/main",
+		"commits": [
+			{"id": "a", "added": [], "modified": [], "removed": ["rfds/0003/README.md", "rfds/0004/README.md", "rfds/0005/diagram.png"]},
+			{"id": "b", "added": ["rfds/0004/README.md"], "modified": [], "removed": ["rfds/0006/README.md"]},
+			{"id": "c", "added": [], "modified": [], "removed": ["rfds/0007/notes/README.md", "0008/README.md"]}
+		]
+	}`
+
+	var event PushEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("Failed to parse push event: %v", err)
+	}
+
+	expected := []string{"0003", "0006"}
+	if got := event.RemovedRFDs("rfds"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got := event.RemovedRFDs(""); !reflect.DeepEqual(got, []string{"0008"}) {
+		t.Errorf("Expected [0008] with no folder, got %v", got)
+	}
+}
@@ -119,7 +119,7 @@ func (c *Client) SendUpdated(old, new *models.RFD) (*Response, error) {
 		return nil, nil
 	}
 
-	changes := detectChanges(old, new)
+	changes := DetectChanges(old, new)
 	if changes == nil {
 		// No changes, don't send webhook
 		return nil, nil
@@ -137,8 +137,8 @@ func (c *Client) SendUpdated(old, new *models.RFD) (*Response, error) {
 	return c.sendSync(payload)
 }
 
-// detectChanges compares old and new RFD and returns changes, or nil if no changes
-func detectChanges(old, new *models.RFD) *RFDChanges {
+// DetectChanges compares old and new RFD and returns changes, or nil if no changes
+func DetectChanges(old, new *models.RFD) *RFDChanges {
 	changes := &RFDChanges{}
 	hasChanges := false
 